@@ -9,6 +9,21 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Collection names shared by main and the aggregations that join collections
+const (
+	BoardsCollection          = "boards"
+	TasksCollection           = "tasks"
+	UsersCollection           = "users"
+	NotificationsCollection   = "notifications"
+	CommentsCollection        = "comments"
+	ColumnLocksCollection     = "column_locks"
+	ActivityCollection        = "activity"
+	WorkLogsCollection        = "work_logs"
+	TimersCollection          = "timers"
+	TemplatesCollection       = "board_templates"
+	AttachmentUsageCollection = "attachment_usage"
+)
+
 func SetupMongoDB(mongoURL string) (*mongo.Database, *mongo.Client, context.Context, context.CancelFunc) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
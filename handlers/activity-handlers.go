@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func actorID(r *http.Request) primitive.ObjectID {
+	userID, _ := middlewares.GetUserID(r)
+	id, _ := primitive.ObjectIDFromHex(userID)
+	return id
+}
+
+// recordActivity stores the entry when there is one. The change already happened, so a failure is only logged.
+func recordActivity(r *http.Request, service *services.ActivityService, activity *models.Activity) {
+	if activity == nil {
+		return
+	}
+	if err := service.Record(r.Context(), activity); err != nil {
+		log.Printf("Error recording %s activity of board %s: %v", activity.Action, activity.BoardID.Hex(), err)
+	}
+}
+
+// taskChanged records the change of a task by the user of the request and applies the completion rules
+// of its boards, see services.TaskActivity and services.CompletionService.TaskChanged
+func (h *TaskHandler) taskChanged(r *http.Request, before, after *models.Task) {
+	recordActivity(r, h.ActivityService, services.TaskActivity(actorID(r), before, after))
+	h.CompletionService.TaskChanged(r.Context(), actorID(r), before, after)
+}
+
+// recordBoardActivity records the change of a board by the user of the request, see services.BoardActivity
+func (h *BoardHandler) recordBoardActivity(r *http.Request, before, after *models.Board) {
+	recordActivity(r, h.ActivityService, services.BoardActivity(actorID(r), before, after))
+}
+
+// snapshotTask reads the task before a partial change, nil when it cannot be read
+func (h *TaskHandler) snapshotTask(r *http.Request, taskId string) *models.Task {
+	task, err := h.Service.GetTaskById(r.Context(), taskId)
+	if err != nil {
+		return nil
+	}
+	return task
+}
+
+// cloneTask copies the task so that changes to the copy, even in place, do not alter the original
+func cloneTask(task *models.Task) *models.Task {
+	clone := *task
+	clone.Checklist = append([]models.ChecklistItem(nil), task.Checklist...)
+	return &clone
+}
+
+func writeActivityPage(w http.ResponseWriter, activity []models.Activity, nextCursor string, err error) {
+	if err != nil {
+		http.Error(w, "Unable to get activity. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"message":     "Activity retrieved successfully",
+		"activity":    activity,
+		"next_cursor": nextCursor,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTaskActivity returns one page of the history of the task, oldest first
+func (h *TaskHandler) GetTaskActivity(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	activity, nextCursor, err := h.ActivityService.GetActivityByTaskId(r.Context(), task.ID.Hex(), page)
+	writeActivityPage(w, activity, nextCursor, err)
+}
+
+// GetBoardActivity returns one page of the history of the board and of its tasks, oldest first
+func (h *BoardHandler) GetBoardActivity(w http.ResponseWriter, r *http.Request) {
+	board, err := h.Service.GetBoardById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+	if !isBoardMember(r, board) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return
+	}
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	activity, nextCursor, err := h.ActivityService.GetActivityByBoardId(r.Context(), board.ID.Hex(), page)
+	writeActivityPage(w, activity, nextCursor, err)
+}
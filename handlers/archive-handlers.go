@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+)
+
+// rejectArchived answers 409 when one of the boards is archived, see middlewares.ArchiveMiddleware
+// for the boards and tasks of the route
+func rejectArchived(w http.ResponseWriter, boards ...*models.Board) bool {
+	for _, board := range boards {
+		if board != nil && board.Archived {
+			http.Error(w, services.ErrBoardArchived.Error(), http.StatusConflict)
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveBoard makes the board read-only and hides it from the default listings
+func (h *BoardHandler) ArchiveBoard(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true, "Board archived successfully")
+}
+
+// UnarchiveBoard makes the board writable again and brings it back to the default listings
+func (h *BoardHandler) UnarchiveBoard(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false, "Board unarchived successfully")
+}
+
+func (h *BoardHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool, message string) {
+	boardId := mux.Vars(r)["id"]
+	before, err := h.Service.GetBoardById(r.Context(), boardId)
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+	if !isBoardMember(r, before) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return
+	}
+
+	board, changed, err := h.Service.SetArchived(r.Context(), boardId, archived)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Board is being modified by other requests, try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Unable to update board. Check Server", http.StatusInternalServerError)
+		return
+	}
+	if changed {
+		h.recordBoardActivity(r, before, board)
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"board":   board,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// multipartOverhead is the room left for the multipart boundaries and headers on top of the file size
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	Service      *services.AttachmentService
+	TaskService  *services.TaskService
+	BoardService *services.BoardService
+}
+
+func NewAttachmentHandler(service *services.AttachmentService, taskService *services.TaskService, boardService *services.BoardService) *AttachmentHandler {
+	return &AttachmentHandler{Service: service, TaskService: taskService, BoardService: boardService}
+}
+
+func (h *AttachmentHandler) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+
+	attachments := task.Attachments
+	if attachments == nil {
+		attachments = []models.Attachment{}
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"message":     "Attachments retrieved successfully",
+		"attachments": attachments,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UploadAttachment reads the "file" part of a multipart/form-data body, skipping the other parts
+// without parsing the form. The file itself is read into memory, bounded by the maximum attachment size.
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	userID, _ := middlewares.GetUserID(r)
+	uploaderID, _ := primitive.ObjectIDFromHex(userID)
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.Service.MaxFileSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "The request must be multipart/form-data", http.StatusBadRequest)
+		return
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var part *multipart.Part
+	for {
+		part, err = reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "Missing file field", http.StatusBadRequest)
+			return
+		}
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, services.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Invalid multipart body", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() == "file" {
+			break
+		}
+		part.Close()
+	}
+	defer part.Close()
+
+	attachment, err := h.Service.Upload(r.Context(), task.ID.Hex(), uploaderID, part.FileName(), part)
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, services.ErrAttachmentTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, services.ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, services.ErrEmptyAttachment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Unable to upload attachment. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":    true,
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	attachmentId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["attachmentId"])
+
+	attachment, content, err := h.Service.Open(r.Context(), task, attachmentId)
+	if errors.Is(err, services.ErrAttachmentNotFound) || errors.Is(err, services.ErrBlobNotFound) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Unable to read attachment. Check Server", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Checksum-Sha256", attachment.Checksum)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("Error sending attachment %s: %v", attachment.ID.Hex(), err)
+	}
+}
+
+// DeleteAttachment is allowed to the uploader and to the owner of the board
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	task, board, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	attachmentId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["attachmentId"])
+	userID, _ := middlewares.GetUserID(r)
+
+	allowed := board.OwnerID.Hex() == userID
+	for _, attachment := range task.Attachments {
+		if attachment.ID == attachmentId && attachment.UploaderID.Hex() == userID {
+			allowed = true
+		}
+	}
+	if !allowed {
+		http.Error(w, "Only the uploader or the board owner can delete this attachment", http.StatusForbidden)
+		return
+	}
+
+	task, err := h.Service.Remove(r.Context(), task.ID.Hex(), attachmentId)
+	switch {
+	case errors.Is(err, services.ErrAttachmentNotFound):
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to delete attachment. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Attachment deleted successfully",
+		"task":    task,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
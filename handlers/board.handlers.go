@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 	"todoerbk/middlewares"
@@ -13,12 +14,31 @@ import (
 )
 
 type BoardHandler struct {
-	Service     *services.BoardService
-	TaskService *services.TaskService
+	Service           *services.BoardService
+	TaskService       *services.TaskService
+	CommentService    *services.CommentService
+	AttachmentService *services.AttachmentService
+	ActivityService   *services.ActivityService
+	WorkLogService    *services.WorkLogService
+	BurndownService   *services.BurndownService
+	RolloverService   *services.RolloverService
+	CompletionService *services.CompletionService
+	UserService       *services.UserService
 }
 
-func NewBoardHandler(service *services.BoardService, taskService *services.TaskService) *BoardHandler {
-	return &BoardHandler{Service: service, TaskService: taskService}
+func NewBoardHandler(service *services.BoardService, taskService *services.TaskService, commentService *services.CommentService, attachmentService *services.AttachmentService, activityService *services.ActivityService, workLogService *services.WorkLogService, burndownService *services.BurndownService, rolloverService *services.RolloverService, completionService *services.CompletionService, userService *services.UserService) *BoardHandler {
+	return &BoardHandler{
+		Service:           service,
+		TaskService:       taskService,
+		CommentService:    commentService,
+		AttachmentService: attachmentService,
+		ActivityService:   activityService,
+		WorkLogService:    workLogService,
+		BurndownService:   burndownService,
+		RolloverService:   rolloverService,
+		CompletionService: completionService,
+		UserService:       userService,
+	}
 }
 
 func (h *BoardHandler) CreateBoard(w http.ResponseWriter, r *http.Request) {
@@ -33,11 +53,35 @@ func (h *BoardHandler) CreateBoard(w http.ResponseWriter, r *http.Request) {
 	board.CreatedAt = now
 	board.UpdatedAt = now
 	board.Completed = false
-	err := h.Service.CreateBoard(r.Context(), &board)
+	board.Version = 1
+	board.RolledOverFrom = nil
+	board.RolledOverTo = nil
+	board.CompletionReason = ""
+	board.Archived = false
+	board.ArchivedAt = nil
+	board.Members = boardMembers(board.OwnerID, board.Members)
+	if !h.checkMembers(w, r, board.Members) {
+		return
+	}
+	for i := range board.Labels {
+		board.Labels[i].ID = primitive.NewObjectID()
+	}
+	if len(board.Workflow) == 0 {
+		board.Workflow = models.DefaultWorkflow()
+	}
+	workflow, err := services.NormalizeWorkflow(board.Workflow)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	board.Workflow = workflow
+
+	err = h.Service.CreateBoard(r.Context(), &board)
 	if err != nil {
 		http.Error(w, "Unable to create board. Check Server", http.StatusInternalServerError)
 		return
 	}
+	h.recordBoardActivity(r, nil, &board)
 
 	response := map[string]interface{}{
 		"success": true,
@@ -46,12 +90,15 @@ func (h *BoardHandler) CreateBoard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *BoardHandler) GetBoards(w http.ResponseWriter, r *http.Request) {
-	boards, err := h.Service.GetBoards(r.Context())
+	query, _ := r.Context().Value(middlewares.BoardQueryKey).(models.BoardQuery)
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	boards, nextCursor, err := h.Service.GetBoards(r.Context(), query, page)
 	if err != nil {
 		http.Error(w, "Unable to get boards. Check Server", http.StatusInternalServerError)
 		return
@@ -62,9 +109,10 @@ func (h *BoardHandler) GetBoards(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := map[string]interface{}{
-		"success": true,
-		"message": "All boards retrieved successfully",
-		"boards":  boards,
+		"success":     true,
+		"message":     "All boards retrieved successfully",
+		"boards":      boards,
+		"next_cursor": nextCursor,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -80,8 +128,10 @@ func (h *BoardHandler) GetBoardById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//Find all tasks associated with the board
-	tasks, err := h.TaskService.GetTasksByBoardId(r.Context(), boardToReturn.ID.Hex())
+	//Find one page of the tasks associated with the board
+	query, _ := r.Context().Value(middlewares.TaskQueryKey).(models.TaskQuery)
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	tasks, nextCursor, err := h.TaskService.GetTasksByBoardId(r.Context(), boardToReturn, query, page)
 	if err != nil {
 		http.Error(w, "Unable to get tasks. Check Server", http.StatusInternalServerError)
 		return
@@ -91,29 +141,74 @@ func (h *BoardHandler) GetBoardById(w http.ResponseWriter, r *http.Request) {
 		tasks = []models.Task{}
 	}
 
+	counts, err := h.TaskService.CountTasksByStatus(r.Context(), boardToReturn.ID)
+	if err != nil {
+		http.Error(w, "Unable to count board tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
+	timeTotals, err := h.TaskService.GetTimeTotals(r.Context(), boardToReturn.ID)
+	if err != nil {
+		http.Error(w, "Unable to add up board time. Check Server", http.StatusInternalServerError)
+		return
+	}
+	completion, err := h.CompletionService.Explain(r.Context(), boardToReturn)
+	if err != nil {
+		http.Error(w, "Unable to count board tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
+
 	response := map[string]interface{}{
-		"success": true,
-		"message": "Board retrieved successfully",
-		"board":   boardToReturn,
-		"tasks":   tasks,
+		"success":      true,
+		"message":      "Board retrieved successfully",
+		"board":        boardToReturn,
+		"column_loads": services.ColumnLoads(*boardToReturn, counts),
+		"time_totals":  timeTotals,
+		"completion":   completion,
+		"tasks":        tasks,
+		"next_cursor":  nextCursor,
 	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(boardToReturn.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *BoardHandler) GetBoardsByUserId(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value(middlewares.UserIDKey).(string)
-	boards, err := h.Service.GetBoardsByOwnerID(r.Context(), userId)
+	query, _ := r.Context().Value(middlewares.BoardQueryKey).(models.BoardQuery)
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+
+	// ?summary=true returns each board with its task counts instead of the plain board
+	if r.URL.Query().Get("summary") == "true" {
+		summaries, nextCursor, err := h.Service.GetBoardSummariesByOwnerID(r.Context(), userId, query, page)
+		if err != nil {
+			http.Error(w, "Unable to get board summaries. Check Server", http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"success":     true,
+			"message":     "Board summaries retrieved successfully",
+			"boards":      summaries,
+			"next_cursor": nextCursor,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	boards, nextCursor, err := h.Service.GetBoardsByOwnerID(r.Context(), userId, query, page)
 	if err != nil {
 		http.Error(w, "Unable to get boards. Check Server", http.StatusInternalServerError)
 		return
 	}
 
 	response := map[string]interface{}{
-		"success": true,
-		"message": "Boards retrieved successfully",
-		"boards":  boards,
+		"success":     true,
+		"message":     "Boards retrieved successfully",
+		"boards":      boards,
+		"next_cursor": nextCursor,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -132,26 +227,66 @@ func (h *BoardHandler) UpdateBoardDetails(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Board to update not found", http.StatusNotFound)
 		return
 	}
+	if !isBoardMember(r, boardToUpdate) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return
+	}
+	before := *boardToUpdate
+
+	if version, ok := middlewares.IfMatchVersion(r, boardToUpdate.Version); ok {
+		boardToUpdate.Version = version
+	}
+
+	// Leaving members out keeps them, and only the owner can change them
+	if boardUpdateBody.Members != nil && !sameMembers(boardUpdateBody.Members, boardToUpdate.Members) {
+		if boardToUpdate.OwnerID != actorID(r) {
+			http.Error(w, "Only the board owner can change its members", http.StatusForbidden)
+			return
+		}
+		members := boardMembers(boardToUpdate.OwnerID, boardUpdateBody.Members)
+		if !h.checkMembers(w, r, members) {
+			return
+		}
+		boardToUpdate.Members = members
+	}
 
 	boardToUpdate.Title = boardUpdateBody.Title
 	boardToUpdate.FromDate = boardUpdateBody.FromDate
 	boardToUpdate.ToDate = boardUpdateBody.ToDate
+	boardToUpdate.AutoRollover = boardUpdateBody.AutoRollover
+	boardToUpdate.CompletionRules = boardUpdateBody.CompletionRules
 
 	now := time.Now().UTC()
 	boardToUpdate.UpdatedAt = now
 
-	err = h.Service.UpdateBoard(r.Context(), boardId, *boardToUpdate)
+	err = h.Service.UpdateBoard(r.Context(), boardId, boardToUpdate)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Board was modified by another request", http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Board to update not found", http.StatusNotFound)
 		return
 	}
 
+	h.recordBoardActivity(r, &before, boardToUpdate)
+
+	// Turning auto-complete on completes a board whose tasks are all done already
+	if boardToUpdate.CompletionRules.AutoComplete && !boardToUpdate.Completed {
+		if err := h.CompletionService.CompleteIfDone(r.Context(), actorID(r), boardToUpdate.ID); err == nil {
+			if completed, err := h.Service.GetBoardById(r.Context(), boardId); err == nil {
+				boardToUpdate = completed
+			}
+		}
+	}
+
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Board updated successfully",
 		"board":   boardToUpdate,
 	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(boardToUpdate.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
@@ -163,26 +298,61 @@ func (h *BoardHandler) UpdateBoardStatus(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Board to update not found", http.StatusNotFound)
 		return
 	}
+	before := *boardToUpdate
+
+	if version, ok := middlewares.IfMatchVersion(r, boardToUpdate.Version); ok {
+		boardToUpdate.Version = version
+	}
 
 	boardToUpdate.Completed = !boardToUpdate.Completed
+	boardToUpdate.CompletionReason = models.ReopenedManually
+	if boardToUpdate.Completed {
+		reason, err := h.CompletionService.CheckManualCompletion(r.Context(), boardToUpdate, isForced(r))
+		if errors.Is(err, services.ErrOpenTasks) {
+			h.writeCompletion(w, r, &before, http.StatusConflict, false, err.Error())
+			return
+		}
+		if err != nil {
+			http.Error(w, "Unable to count board tasks. Check Server", http.StatusInternalServerError)
+			return
+		}
+		boardToUpdate.CompletionReason = reason
+	}
 
 	now := time.Now().UTC()
 	boardToUpdate.UpdatedAt = now
 
-	err = h.Service.UpdateBoard(r.Context(), boardId, *boardToUpdate)
-
+	err = h.Service.UpdateBoard(r.Context(), boardId, boardToUpdate)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Board was modified by another request", http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Board to update not found", http.StatusNotFound)
 		return
 	}
 
+	h.recordBoardActivity(r, &before, boardToUpdate)
+	h.writeCompletion(w, r, boardToUpdate, http.StatusOK, true, "Board completed updated successfully")
+}
+
+// writeCompletion returns the board with the explanation of why it is or is not complete
+func (h *BoardHandler) writeCompletion(w http.ResponseWriter, r *http.Request, board *models.Board, status int, success bool, message string) {
+	completion, err := h.CompletionService.Explain(r.Context(), board)
+	if err != nil {
+		http.Error(w, "Unable to count board tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
+
 	response := map[string]interface{}{
-		"success": true,
-		"message": "Board completed updated successfully",
-		"board":   boardToUpdate,
+		"success":    success,
+		"message":    message,
+		"board":      board,
+		"completion": completion,
 	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -193,18 +363,41 @@ func (h *BoardHandler) DeleteBoardByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Board to delete not found", http.StatusNotFound)
 		return
 	}
-	err = h.Service.DeleteBoard(r.Context(), boardToDelete.ID.Hex())
+	version, _ := middlewares.IfMatchVersion(r, boardToDelete.Version)
+	err = h.Service.DeleteBoardAtVersion(r.Context(), boardToDelete.ID.Hex(), version)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Board was modified by another request", http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Board to delete not found", http.StatusNotFound)
 		return
 	}
 
-	//Delete all tasks associated with the board
+	h.recordBoardActivity(r, boardToDelete, nil)
+
+	//Delete all tasks associated with the board, keeping their attachments to clean up the blobs
+	attachments, err := h.TaskService.GetAttachmentsByBoardId(r.Context(), boardToDelete.ID.Hex())
+	if err != nil {
+		http.Error(w, "Unable to delete tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
 	err = h.TaskService.DeleteTasksByBoardId(r.Context(), boardToDelete.ID.Hex())
 	if err != nil {
 		http.Error(w, "Unable to delete tasks. Check Server", http.StatusInternalServerError)
 		return
 	}
+	h.AttachmentService.DeleteBlobs(r.Context(), attachments)
+	err = h.CommentService.DeleteCommentsByBoardId(r.Context(), boardToDelete.ID.Hex())
+	if err != nil {
+		http.Error(w, "Unable to delete comments. Check Server", http.StatusInternalServerError)
+		return
+	}
+	err = h.WorkLogService.DeleteWorkLogsByBoardId(r.Context(), boardToDelete.ID.Hex())
+	if err != nil {
+		http.Error(w, "Unable to delete work logs. Check Server", http.StatusInternalServerError)
+		return
+	}
 
 	response := map[string]interface{}{
 		"success": true,
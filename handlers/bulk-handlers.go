@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func uniqueObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := map[primitive.ObjectID]bool{}
+	unique := []primitive.ObjectID{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// bulkItemResult reports the outcome for one task, hiding the details of unexpected errors
+func bulkItemResult(taskID primitive.ObjectID, task *models.Task, err error) models.BulkItemResult {
+	if err == nil {
+		return models.BulkItemResult{TaskID: taskID, Success: true, Task: task}
+	}
+	var itemErr *itemError
+	var wipErr *services.WIPLimitError
+	if !errors.As(err, &itemErr) && !errors.As(err, &wipErr) && !errors.Is(err, services.ErrColumnBusy) && !errors.Is(err, services.ErrInvalidMove) {
+		log.Printf("Error in bulk operation on task %s: %v", taskID.Hex(), err)
+		err = errors.New("Unable to update task. Check Server")
+	}
+	return models.BulkItemResult{TaskID: taskID, Error: err.Error()}
+}
+
+func writeBulkResults(w http.ResponseWriter, message string, results []models.BulkItemResult) {
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+	response := map[string]interface{}{
+		"success":   succeeded == len(results),
+		"message":   message,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// setTaskStatus puts the task at the end of the status column, with the checks of a drag and drop
+func (h *TaskHandler) setTaskStatus(r *http.Request, task *models.Task, board *models.Board, status models.TaskStatus) (*models.Task, error) {
+	column, err := workflowColumn(board, status)
+	if err != nil {
+		return nil, &itemError{http.StatusBadRequest, err.Error()}
+	}
+	if column.Status == task.Status {
+		return task, nil
+	}
+	if !board.CanTransition(task.Status, column.Status) {
+		return nil, &itemError{http.StatusConflict, "The board workflow does not allow moving a task from " + string(task.Status) + " to " + string(column.Status)}
+	}
+	if column.Category != models.CategoryTodo && !isForced(r) {
+		blockers, err := h.Service.GetUnfinishedBlockers(r.Context(), task)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 {
+			return nil, &itemError{http.StatusConflict, "Task has unfinished blockers. Finish them first or retry with ?force=true"}
+		}
+	}
+
+	var moved *models.Task
+	err = h.Service.EnterColumn(r.Context(), board.ID, task.ID, task.Status, *column, isForced(r), func() error {
+		moved, err = h.Service.MoveTask(r.Context(), task.ID.Hex(), models.MoveTaskRequest{Status: column.Status}, *column)
+		return err
+	})
+	if err != nil {
+		return nil, transferWriteError(err, false)
+	}
+	h.taskChanged(r, task, moved)
+	h.completeOccurrence(r, moved, task.StatusCategory)
+	return moved, nil
+}
+
+// checkBulkItem tells why the operation cannot apply to the task, labels and assignees belong to its board
+func checkBulkItem(request models.BulkTaskRequest, board *models.Board) error {
+	switch request.Operation {
+	case models.BulkAddLabels:
+		if err := validateLabels(models.Task{LabelIDs: request.LabelIDs}, board); err != nil {
+			return &itemError{http.StatusBadRequest, err.Error()}
+		}
+	case models.BulkAssign:
+		if err := validateAssignees(models.Task{Assignees: request.AssigneeIDs}, board); err != nil {
+			return &itemError{http.StatusBadRequest, err.Error()}
+		}
+	}
+	return nil
+}
+
+// BulkTasks applies one operation to many tasks and reports the outcome of each one.
+// Priority, label and assignee changes and deletes run as a single write; status changes
+// and moves need a rank and the column checks of each task, so they run task by task.
+func (h *TaskHandler) BulkTasks(w http.ResponseWriter, r *http.Request) {
+	bulkRequest, ok := r.Context().Value(middlewares.BulkTaskRequestKey).(models.BulkTaskRequest)
+	if !ok {
+		http.Error(w, "Unable to process bulk operation. Check Server", http.StatusInternalServerError)
+		return
+	}
+	var target *models.Board
+	if bulkRequest.Operation == models.BulkMove {
+		if target, ok = h.loadTransferTarget(w, r, *bulkRequest.BoardID); !ok {
+			return
+		}
+	}
+
+	ids := uniqueObjectIDs(bulkRequest.TaskIDs)
+	tasks, err := h.Service.GetTasksByIds(r.Context(), ids)
+	if err != nil {
+		http.Error(w, "Unable to get tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
+	byID := map[primitive.ObjectID]*models.Task{}
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	boards := map[primitive.ObjectID]*models.Board{}
+	results := make([]models.BulkItemResult, len(ids))
+	eligible := []primitive.ObjectID{}
+	for i, id := range ids {
+		task, ok := byID[id]
+		if !ok {
+			results[i] = bulkItemResult(id, nil, &itemError{http.StatusNotFound, "Task not found"})
+			continue
+		}
+		board, ok := boards[task.BoardID]
+		if !ok {
+			board, err = h.BoardService.GetBoardById(r.Context(), task.BoardID.Hex())
+			if err != nil {
+				board = nil
+			}
+			boards[task.BoardID] = board
+		}
+		if board == nil {
+			results[i] = bulkItemResult(id, nil, &itemError{http.StatusNotFound, "Board of the task not found"})
+			continue
+		}
+		if !isBoardMember(r, board) {
+			results[i] = bulkItemResult(id, nil, &itemError{http.StatusForbidden, "You are not a member of the board of the task"})
+			continue
+		}
+		if board.Archived {
+			results[i] = bulkItemResult(id, nil, &itemError{http.StatusConflict, services.ErrBoardArchived.Error()})
+			continue
+		}
+		if err := checkBulkItem(bulkRequest, board); err != nil {
+			results[i] = bulkItemResult(id, nil, err)
+			continue
+		}
+
+		switch bulkRequest.Operation {
+		case models.BulkSetStatus:
+			updated, err := h.setTaskStatus(r, task, board, bulkRequest.Status)
+			results[i] = bulkItemResult(id, updated, err)
+		case models.BulkMove:
+			updated, err := h.transferTask(r, id, target, bulkRequest.Status, false)
+			results[i] = bulkItemResult(id, updated, err)
+		default:
+			eligible = append(eligible, id)
+		}
+	}
+
+	switch bulkRequest.Operation {
+	case models.BulkSetPriority:
+		err = h.Service.SetPriority(r.Context(), eligible, bulkRequest.Priority)
+	case models.BulkAddLabels:
+		err = h.Service.AddLabels(r.Context(), eligible, bulkRequest.LabelIDs)
+	case models.BulkRemoveLabels:
+		err = h.Service.RemoveLabels(r.Context(), eligible, bulkRequest.LabelIDs)
+	case models.BulkAssign:
+		err = h.Service.AddAssignees(r.Context(), eligible, bulkRequest.AssigneeIDs)
+	case models.BulkUnassign:
+		err = h.Service.RemoveAssignees(r.Context(), eligible, bulkRequest.AssigneeIDs)
+	case models.BulkDelete:
+		err = h.deleteTasks(r, eligible, byID)
+	}
+
+	updated := map[primitive.ObjectID]*models.Task{}
+	if err == nil && len(eligible) > 0 && bulkRequest.Operation != models.BulkDelete {
+		var tasks []models.Task
+		tasks, err = h.Service.GetTasksByIds(r.Context(), eligible)
+		for i := range tasks {
+			updated[tasks[i].ID] = &tasks[i]
+		}
+	}
+	for i, id := range ids {
+		if !containsObjectID(eligible, id) {
+			continue
+		}
+		results[i] = bulkItemResult(id, updated[id], err)
+		if err != nil {
+			continue
+		}
+		if bulkRequest.Operation == models.BulkDelete {
+			h.taskChanged(r, byID[id], nil)
+			continue
+		}
+		if updated[id] != nil {
+			h.taskChanged(r, byID[id], updated[id])
+		}
+		if bulkRequest.Operation == models.BulkAssign && updated[id] != nil {
+			h.notifyNewAssignees(r, *updated[id], byID[id].Assignees)
+		}
+	}
+
+	writeBulkResults(w, "Bulk operation "+string(bulkRequest.Operation)+" applied", results)
+}
+
+// deleteTasks deletes the tasks at once, then their comments, work logs and attachment contents
+func (h *TaskHandler) deleteTasks(r *http.Request, ids []primitive.ObjectID, tasks map[primitive.ObjectID]*models.Task) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := h.Service.DeleteTasks(r.Context(), ids); err != nil {
+		return err
+	}
+	if err := h.CommentService.DeleteCommentsByTaskIds(r.Context(), ids); err != nil {
+		log.Printf("Error deleting comments of deleted tasks: %v", err)
+	}
+	if err := h.WorkLogService.DeleteWorkLogsByTaskIds(r.Context(), ids); err != nil {
+		log.Printf("Error deleting work logs of deleted tasks: %v", err)
+	}
+	for _, id := range ids {
+		h.AttachmentService.DeleteBlobs(r.Context(), tasks[id].Attachments)
+	}
+	return nil
+}
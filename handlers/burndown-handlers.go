@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+
+	"github.com/gorilla/mux"
+)
+
+// GetBurndown returns the daily remaining work of the board over its date range, with the ideal line
+// and the changes of scope, by task count or by story points
+func (h *BoardHandler) GetBurndown(w http.ResponseWriter, r *http.Request) {
+	board, err := h.Service.GetBoardById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+	if !isBoardMember(r, board) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return
+	}
+	query, _ := r.Context().Value(middlewares.BurndownQueryKey).(models.BurndownQuery)
+	burndown, err := h.BurndownService.GetBurndown(r.Context(), board, query.Unit, time.Now().UTC())
+	if err != nil {
+		http.Error(w, "Unable to get burndown. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":  true,
+		"message":  "Burndown retrieved successfully",
+		"burndown": burndown,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *TaskHandler) AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	itemRequest, ok := r.Context().Value(middlewares.ChecklistItemRequestKey).(models.ChecklistItemRequest)
+	if !ok {
+		http.Error(w, "Unable to process checklist item. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, board, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok || !checkChecklistAssignee(w, board, itemRequest.AssigneeID) {
+		return
+	}
+
+	task, err := h.Service.AddChecklistItem(r.Context(), before.ID.Hex(), models.ChecklistItem{
+		Text:       itemRequest.Text,
+		AssigneeID: itemRequest.AssigneeID,
+	})
+	h.writeChecklistResponse(w, r, before, task, err, "Checklist item added successfully", http.StatusCreated)
+}
+
+func (h *TaskHandler) UpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	updateRequest, ok := r.Context().Value(middlewares.ChecklistItemUpdateRequestKey).(models.ChecklistItemUpdateRequest)
+	if !ok {
+		http.Error(w, "Unable to process checklist item. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, board, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok || !checkChecklistAssignee(w, board, updateRequest.AssigneeID) {
+		return
+	}
+	itemId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["itemId"])
+
+	task, err := h.Service.UpdateChecklistItem(r.Context(), before.ID.Hex(), itemId, updateRequest)
+	h.writeChecklistResponse(w, r, before, task, err, "Checklist item updated successfully", http.StatusOK)
+}
+
+func (h *TaskHandler) ToggleChecklistItem(w http.ResponseWriter, r *http.Request) {
+	before, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	itemId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["itemId"])
+
+	task, err := h.Service.ToggleChecklistItem(r.Context(), before.ID.Hex(), itemId)
+	h.writeChecklistResponse(w, r, before, task, err, "Checklist item toggled successfully", http.StatusOK)
+}
+
+func (h *TaskHandler) ReorderChecklist(w http.ResponseWriter, r *http.Request) {
+	orderRequest, ok := r.Context().Value(middlewares.ChecklistOrderRequestKey).(models.ChecklistOrderRequest)
+	if !ok {
+		http.Error(w, "Unable to process checklist order. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+
+	task, err := h.Service.ReorderChecklist(r.Context(), before.ID.Hex(), orderRequest.ItemIDs)
+	h.writeChecklistResponse(w, r, before, task, err, "Checklist reordered successfully", http.StatusOK)
+}
+
+func (h *TaskHandler) RemoveChecklistItem(w http.ResponseWriter, r *http.Request) {
+	before, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	itemId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["itemId"])
+
+	task, err := h.Service.RemoveChecklistItem(r.Context(), before.ID.Hex(), itemId)
+	h.writeChecklistResponse(w, r, before, task, err, "Checklist item removed successfully", http.StatusOK)
+}
+
+// checkChecklistAssignee rejects checklist assignees that are not members of the task board
+func checkChecklistAssignee(w http.ResponseWriter, board *models.Board, assigneeID *primitive.ObjectID) bool {
+	if assigneeID == nil {
+		return true
+	}
+	if !board.IsMember(*assigneeID) {
+		http.Error(w, "assignee "+assigneeID.Hex()+" is not a member of the board", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (h *TaskHandler) writeChecklistResponse(w http.ResponseWriter, r *http.Request, before, task *models.Task, err error, message string, status int) {
+	switch {
+	case errors.Is(err, services.ErrChecklistItemNotFound):
+		http.Error(w, "Checklist item not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrInvalidChecklistOrder):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	h.taskChanged(r, before, task)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"task":    task,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
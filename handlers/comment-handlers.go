@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CommentHandler struct {
+	Service             *services.CommentService
+	TaskService         *services.TaskService
+	BoardService        *services.BoardService
+	NotificationService *services.NotificationService
+}
+
+func NewCommentHandler(service *services.CommentService, taskService *services.TaskService, boardService *services.BoardService, notificationService *services.NotificationService) *CommentHandler {
+	return &CommentHandler{Service: service, TaskService: taskService, BoardService: boardService, NotificationService: notificationService}
+}
+
+// loadTaskForMember returns the task of the route and its board when the user is a member of the board
+func loadTaskForMember(w http.ResponseWriter, r *http.Request, taskService *services.TaskService, boardService *services.BoardService) (*models.Task, *models.Board, bool) {
+	task, err := taskService.GetTaskById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	board, err := boardService.GetBoardById(r.Context(), task.BoardID.Hex())
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	userID, _ := middlewares.GetUserID(r)
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	if !board.IsMember(userObjectID) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return nil, nil, false
+	}
+	return task, board, true
+}
+
+// loadOwnComment returns the comment of the route when it belongs to the task and was written by the user
+func (h *CommentHandler) loadOwnComment(w http.ResponseWriter, r *http.Request) (*models.Comment, bool) {
+	comment, err := h.Service.GetCommentById(r.Context(), mux.Vars(r)["commentId"])
+	if err != nil || comment.TaskID.Hex() != mux.Vars(r)["id"] {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return nil, false
+	}
+	userID, _ := middlewares.GetUserID(r)
+	if comment.AuthorID.Hex() != userID {
+		http.Error(w, "Only the author can change this comment", http.StatusForbidden)
+		return nil, false
+	}
+	return comment, true
+}
+
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	comments, nextCursor, err := h.Service.GetCommentsByTaskId(r.Context(), task.ID.Hex(), page)
+	if err != nil {
+		http.Error(w, "Unable to get comments. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"message":     "Comments retrieved successfully",
+		"comments":    comments,
+		"next_cursor": nextCursor,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	commentRequest, ok := r.Context().Value(middlewares.CommentRequestKey).(models.CommentRequest)
+	if !ok {
+		http.Error(w, "Unable to process comment. Check Server", http.StatusInternalServerError)
+		return
+	}
+	task, board, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	userID, _ := middlewares.GetUserID(r)
+	authorID, _ := primitive.ObjectIDFromHex(userID)
+
+	now := time.Now().UTC()
+	comment := models.Comment{
+		ID:        primitive.NewObjectID(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		TaskID:    task.ID,
+		BoardID:   task.BoardID,
+		AuthorID:  authorID,
+		Body:      commentRequest.Body,
+	}
+	if err := h.Service.CreateComment(r.Context(), board, &comment); err != nil {
+		http.Error(w, "Unable to create comment. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	h.notifyMentions(r, *task, comment, nil)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Comment created successfully",
+		"comment": comment,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	commentRequest, ok := r.Context().Value(middlewares.CommentRequestKey).(models.CommentRequest)
+	if !ok {
+		http.Error(w, "Unable to process comment. Check Server", http.StatusInternalServerError)
+		return
+	}
+	task, board, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	comment, ok := h.loadOwnComment(w, r)
+	if !ok {
+		return
+	}
+
+	previousMentions := comment.Mentions
+	if err := h.Service.UpdateCommentBody(r.Context(), board, comment, commentRequest.Body); err != nil {
+		http.Error(w, "Unable to update comment. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	// Only the users mentioned for the first time in this edit are notified
+	h.notifyMentions(r, *task, *comment, previousMentions)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Comment updated successfully",
+		"comment": comment,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := h.loadOwnComment(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteComment(r.Context(), comment.ID.Hex()); err != nil {
+		http.Error(w, "Unable to delete comment. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Comment with id " + comment.ID.Hex() + " deleted successfully",
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// notifyMentions tells the users mentioned in the comment, except the author and those already mentioned before
+func (h *CommentHandler) notifyMentions(r *http.Request, task models.Task, comment models.Comment, previous []primitive.ObjectID) {
+	for _, userID := range comment.Mentions {
+		if userID == comment.AuthorID || containsObjectID(previous, userID) {
+			continue
+		}
+		taskID, boardID := task.ID, task.BoardID
+		err := h.NotificationService.Notify(r.Context(), &models.Notification{
+			UserID:  userID,
+			Type:    models.TaskMentionNotification,
+			Message: "You were mentioned in a comment on task \"" + task.Title + "\"",
+			TaskID:  &taskID,
+			BoardID: &boardID,
+		})
+		if err != nil {
+			log.Printf("Error notifying mention of %s in comment %s: %v", userID.Hex(), comment.ID.Hex(), err)
+		}
+	}
+}
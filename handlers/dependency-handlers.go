@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *TaskHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	dependencyRequest, ok := r.Context().Value(middlewares.DependencyRequestKey).(models.DependencyRequest)
+	if !ok {
+		http.Error(w, "Unable to process dependency. Check Server", http.StatusInternalServerError)
+		return
+	}
+	task, board, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	otherID := dependencyRequest.Blocks
+	if dependencyRequest.BlockedBy != nil {
+		otherID = dependencyRequest.BlockedBy
+	}
+	other, err := h.Service.GetTaskById(r.Context(), otherID.Hex())
+	if err != nil {
+		http.Error(w, "Task "+otherID.Hex()+" not found", http.StatusNotFound)
+		return
+	}
+	otherBoard := board
+	if other.BoardID != task.BoardID {
+		otherBoard, err = h.BoardService.GetBoardById(r.Context(), other.BoardID.Hex())
+		if err != nil {
+			http.Error(w, "Board not found", http.StatusNotFound)
+			return
+		}
+		if !isBoardMember(r, otherBoard) {
+			http.Error(w, "You are not a member of the board of task "+other.ID.Hex(), http.StatusForbidden)
+			return
+		}
+		// The task of the route is checked by middlewares.ArchiveMiddleware, the other one is not
+		if rejectArchived(w, otherBoard) {
+			return
+		}
+		if board.OwnerID != otherBoard.OwnerID {
+			http.Error(w, "Dependencies are only allowed between boards of the same owner", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The task in the path is blocked by the other one, or blocks it
+	blocked, blocker := task, other
+	if dependencyRequest.BlockedBy == nil {
+		blocked, blocker = other, task
+	}
+
+	updated, err := h.Service.AddDependency(r.Context(), board.OwnerID, blocked.ID, blocker.ID)
+	switch {
+	case errors.Is(err, services.ErrSelfDependency), errors.Is(err, services.ErrDependencyCycle):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrDependenciesBusy):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to add dependency. Check Server", http.StatusInternalServerError)
+		return
+	}
+	h.taskChanged(r, blocked, updated)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Dependency added successfully",
+		"task":    updated,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TaskHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	before, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	blockerId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["blockerId"])
+
+	task, err := h.Service.RemoveDependency(r.Context(), before.ID, blockerId)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	h.taskChanged(r, before, task)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Dependency removed successfully",
+		"task":    task,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TaskHandler) GetTaskDependencies(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+
+	blockedBy, err := h.Service.GetBlockers(r.Context(), task)
+	if err != nil {
+		http.Error(w, "Unable to get dependencies. Check Server", http.StatusInternalServerError)
+		return
+	}
+	blocks, err := h.Service.GetBlockedTasks(r.Context(), task.ID)
+	if err != nil {
+		http.Error(w, "Unable to get dependencies. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":    true,
+		"message":    "Dependencies retrieved successfully",
+		"blocked_by": blockedBy,
+		"blocks":     blocks,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BoardHandler) GetBoardDependencies(w http.ResponseWriter, r *http.Request) {
+	boardId := mux.Vars(r)["id"]
+	board, err := h.Service.GetBoardById(r.Context(), boardId)
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+	if !isBoardMember(r, board) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return
+	}
+
+	graph, err := h.TaskService.GetBoardDependencyGraph(r.Context(), board.ID)
+	if err != nil {
+		http.Error(w, "Unable to get dependencies. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Dependency graph retrieved successfully",
+		"graph":   graph,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// isForced tells if the caller asked to skip soft rules such as unfinished blockers with ?force=true
+func isForced(r *http.Request) bool {
+	return r.URL.Query().Get("force") == "true"
+}
+
+// checkBlockers answers 409 and returns false when the task would go to an in progress or done
+// column while its blockers are unfinished. ?force=true skips the check.
+func (h *TaskHandler) checkBlockers(w http.ResponseWriter, r *http.Request, task *models.Task, column *models.WorkflowColumn) bool {
+	startsWork := column.Category != models.CategoryTodo
+	if !startsWork || column.Status == task.Status || isForced(r) {
+		return true
+	}
+	blockers, err := h.Service.GetUnfinishedBlockers(r.Context(), task)
+	if err != nil {
+		http.Error(w, "Unable to check task blockers. Check Server", http.StatusInternalServerError)
+		return false
+	}
+	if len(blockers) > 0 {
+		writeBlockersError(w, blockers)
+		return false
+	}
+	return true
+}
+
+// writeBlockersError answers with the blockers that prevent the task from moving forward
+func writeBlockersError(w http.ResponseWriter, blockers []models.Task) {
+	errs := []string{}
+	for _, blocker := range blockers {
+		errs = append(errs, "Blocked by task \""+blocker.Title+"\" ("+blocker.ID.Hex()+") with status "+string(blocker.Status))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	response := map[string]interface{}{
+		"success": false,
+		"message": "Task has unfinished blockers. Finish them first or retry with ?force=true",
+		"errors":  errs,
+	}
+	json.NewEncoder(w).Encode(response)
+}
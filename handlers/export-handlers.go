@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+)
+
+// ExportBoard returns the board with all of its tasks as a JSON document or as CSV, one task per row.
+// Archived boards can be exported like any other.
+func (h *BoardHandler) ExportBoard(w http.ResponseWriter, r *http.Request) {
+	board, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+	query, _ := r.Context().Value(middlewares.ExportQueryKey).(models.ExportQuery)
+
+	tasks, err := h.TaskService.GetAllTasksOfBoard(r.Context(), board)
+	if err != nil {
+		http.Error(w, "Unable to export board. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	if query.CSV {
+		writeBoardCSV(w, board, tasks)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Board exported successfully",
+		"board":   board,
+		"tasks":   tasks,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="board-`+board.ID.Hex()+`.json"`)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeBoardCSV(w http.ResponseWriter, board *models.Board, tasks []models.Task) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="board-`+board.ID.Hex()+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"task_id", "title", "status", "status_category", "priority", "due_date", "assignees", "labels",
+		"story_points", "original_estimate_minutes", "remaining_estimate_minutes", "logged_minutes",
+		"checklist_done", "checklist_total", "created_at", "updated_at"})
+	for _, task := range tasks {
+		dueDate := ""
+		if task.DueDate != nil {
+			dueDate = task.DueDate.UTC().Format(time.RFC3339)
+		}
+		assignees := make([]string, 0, len(task.Assignees))
+		for _, assignee := range task.Assignees {
+			assignees = append(assignees, assignee.Hex())
+		}
+		labels := make([]string, 0, len(task.LabelIDs))
+		for _, labelID := range task.LabelIDs {
+			if label, ok := board.Label(labelID); ok {
+				labels = append(labels, label.Name)
+			}
+		}
+		writer.Write([]string{
+			task.ID.Hex(),
+			task.Title,
+			string(task.Status),
+			string(task.StatusCategory),
+			string(task.Priority),
+			dueDate,
+			strings.Join(assignees, " "),
+			strings.Join(labels, ";"),
+			strconv.Itoa(task.StoryPoints),
+			strconv.Itoa(task.OriginalEstimate),
+			strconv.Itoa(task.RemainingEstimate),
+			strconv.Itoa(task.LoggedMinutes),
+			strconv.Itoa(task.ChecklistProgress.Done),
+			strconv.Itoa(task.ChecklistProgress.Total),
+			task.CreatedAt.UTC().Format(time.RFC3339),
+			task.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+}
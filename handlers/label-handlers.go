@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// loadMemberBoard returns the board of the route when the user of the request is a member of it
+func (h *BoardHandler) loadMemberBoard(w http.ResponseWriter, r *http.Request) (*models.Board, bool) {
+	board, err := h.Service.GetBoardById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !isBoardMember(r, board) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return nil, false
+	}
+	return board, true
+}
+
+func (h *BoardHandler) GetLabels(w http.ResponseWriter, r *http.Request) {
+	board, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+
+	labels := board.Labels
+	if labels == nil {
+		labels = []models.Label{}
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Labels retrieved successfully",
+		"labels":  labels,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *BoardHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	labelRequest, ok := r.Context().Value(middlewares.LabelRequestKey).(models.LabelRequest)
+	if !ok {
+		http.Error(w, "Unable to process label. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+
+	board, err := h.Service.AddLabel(r.Context(), before.ID.Hex(), models.Label{Name: labelRequest.Name, Color: labelRequest.Color})
+	h.writeLabelResponse(w, r, before, board, err, "Label created successfully", http.StatusCreated)
+}
+
+func (h *BoardHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	labelRequest, ok := r.Context().Value(middlewares.LabelRequestKey).(models.LabelRequest)
+	if !ok {
+		http.Error(w, "Unable to process label. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+	labelId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["labelId"])
+
+	board, err := h.Service.UpdateLabel(r.Context(), before.ID.Hex(), labelId, labelRequest)
+	h.writeLabelResponse(w, r, before, board, err, "Label updated successfully", http.StatusOK)
+}
+
+func (h *BoardHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	before, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+	labelId, _ := primitive.ObjectIDFromHex(mux.Vars(r)["labelId"])
+
+	board, err := h.Service.RemoveLabel(r.Context(), before.ID.Hex(), labelId)
+	if err == nil {
+		//Remove the label from every task that uses it
+		if err := h.TaskService.RemoveLabelFromTasks(r.Context(), board.ID, labelId); err != nil {
+			http.Error(w, "Unable to remove label from tasks. Check Server", http.StatusInternalServerError)
+			return
+		}
+	}
+	h.writeLabelResponse(w, r, before, board, err, "Label deleted successfully", http.StatusOK)
+}
+
+func (h *BoardHandler) writeLabelResponse(w http.ResponseWriter, r *http.Request, before, board *models.Board, err error, message string, status int) {
+	switch {
+	case errors.Is(err, services.ErrLabelNotFound):
+		http.Error(w, "Label not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrDuplicateLabel):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Board is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+	h.recordBoardActivity(r, before, board)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"labels":  board.Labels,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// checkMembers rejects member lists with ids that belong to no user
+func (h *BoardHandler) checkMembers(w http.ResponseWriter, r *http.Request, members []primitive.ObjectID) bool {
+	unknown, err := h.UserService.UnknownUserIDs(r.Context(), members)
+	if err != nil {
+		http.Error(w, "Unable to check board members. Check Server", http.StatusInternalServerError)
+		return false
+	}
+	if len(unknown) > 0 {
+		http.Error(w, "member "+unknown[0].Hex()+" is not a user", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// boardMembers removes the duplicates and the owner from a member list, the owner is always a member
+func boardMembers(ownerID primitive.ObjectID, members []primitive.ObjectID) []primitive.ObjectID {
+	result := []primitive.ObjectID{}
+	for _, member := range uniqueObjectIDs(members) {
+		if member != ownerID {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+// sameMembers tells if both lists hold the same users, in any order
+func sameMembers(a, b []primitive.ObjectID) bool {
+	a, b = uniqueObjectIDs(a), uniqueObjectIDs(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !containsObjectID(b, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadOwnBoard returns the board of the route when the user of the request owns it
+func (h *BoardHandler) loadOwnBoard(w http.ResponseWriter, r *http.Request) (*models.Board, bool) {
+	board, err := h.Service.GetBoardById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return nil, false
+	}
+	if board.OwnerID != actorID(r) {
+		http.Error(w, "Only the board owner can change its members", http.StatusForbidden)
+		return nil, false
+	}
+	return board, true
+}
+
+// AddBoardMember gives a user access to the board, only its owner can do it
+func (h *BoardHandler) AddBoardMember(w http.ResponseWriter, r *http.Request) {
+	memberRequest, ok := r.Context().Value(middlewares.BoardMemberRequestKey).(models.BoardMemberRequest)
+	if !ok {
+		http.Error(w, "Unable to process member. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, ok := h.loadOwnBoard(w, r)
+	if !ok || !h.checkMembers(w, r, []primitive.ObjectID{memberRequest.UserID}) {
+		return
+	}
+
+	board, err := h.Service.AddMember(r.Context(), before.ID.Hex(), memberRequest.UserID)
+	h.writeMembersResponse(w, r, before, board, err, "Member added successfully", http.StatusCreated)
+}
+
+// RemoveBoardMember takes the access to the board away from a user, only its owner can do it
+func (h *BoardHandler) RemoveBoardMember(w http.ResponseWriter, r *http.Request) {
+	userID, _ := primitive.ObjectIDFromHex(mux.Vars(r)["userId"])
+	before, ok := h.loadOwnBoard(w, r)
+	if !ok {
+		return
+	}
+
+	board, err := h.Service.RemoveMember(r.Context(), before.ID.Hex(), userID)
+	h.writeMembersResponse(w, r, before, board, err, "Member removed successfully", http.StatusOK)
+}
+
+func (h *BoardHandler) writeMembersResponse(w http.ResponseWriter, r *http.Request, before, board *models.Board, err error, message string, status int) {
+	switch {
+	case errors.Is(err, services.ErrAlreadyMember):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrNotMember):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Board is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to update board members. Check Server", http.StatusInternalServerError)
+		return
+	}
+	h.recordBoardActivity(r, before, board)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"board":   board,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
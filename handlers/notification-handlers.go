@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+)
+
+type NotificationHandler struct {
+	Service *services.NotificationService
+}
+
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{Service: service}
+}
+
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(middlewares.UserIDKey).(string)
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	notifications, nextCursor, err := h.Service.GetNotificationsByUserID(r.Context(), userId, page)
+	if err != nil {
+		http.Error(w, "Unable to get notifications. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":       true,
+		"message":       "Notifications retrieved successfully",
+		"notifications": notifications,
+		"next_cursor":   nextCursor,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *NotificationHandler) MarkNotificationAsRead(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(middlewares.UserIDKey).(string)
+	notificationId := mux.Vars(r)["id"]
+	err := h.Service.MarkAsRead(r.Context(), notificationId, userId)
+	if err != nil {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Notification marked as read",
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// completeOccurrence generates the next occurrence when a recurring task reaches a done column.
+// It is best effort: the recurrence scheduler retries the occurrences that could not be generated.
+func (h *TaskHandler) completeOccurrence(r *http.Request, task *models.Task, previousCategory models.StatusCategory) *models.Task {
+	if task.Recurrence == nil || task.StatusCategory != models.CategoryDone || previousCategory == models.CategoryDone {
+		return nil
+	}
+	next, err := h.RecurrenceService.GenerateNext(r.Context(), task)
+	if err != nil {
+		log.Printf("Error generating next occurrence of task %s: %v", task.ID.Hex(), err)
+		return nil
+	}
+	h.taskChanged(r, nil, next)
+	return next
+}
+
+// cleanupDeletedTask removes what belongs to a task that was just deleted
+func (h *TaskHandler) cleanupDeletedTask(r *http.Request, task *models.Task) error {
+	if err := h.CommentService.DeleteCommentsByTaskId(r.Context(), task.ID.Hex()); err != nil {
+		return err
+	}
+	if err := h.WorkLogService.DeleteWorkLogsByTaskIds(r.Context(), []primitive.ObjectID{task.ID}); err != nil {
+		return err
+	}
+	h.AttachmentService.DeleteBlobs(r.Context(), task.Attachments)
+	return nil
+}
+
+func (h *TaskHandler) writeRecurrenceResponse(w http.ResponseWriter, r *http.Request, before, task *models.Task, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRecurrence), errors.Is(err, services.ErrRecurrenceNeedsDueDate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrNotRecurring):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to update recurrence. Check Server", http.StatusInternalServerError)
+		return
+	}
+	h.taskChanged(r, before, task)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"task":    task,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateRecurrence changes the rule of the series, or makes the task recurring
+func (h *TaskHandler) UpdateRecurrence(w http.ResponseWriter, r *http.Request) {
+	recurrenceRequest, ok := r.Context().Value(middlewares.RecurrenceRequestKey).(models.RecurrenceRequest)
+	if !ok {
+		http.Error(w, "Unable to process recurrence. Check Server", http.StatusInternalServerError)
+		return
+	}
+	before, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	task, err := h.RecurrenceService.UpdateSeries(r.Context(), before.ID.Hex(), recurrenceRequest.Rule)
+	h.writeRecurrenceResponse(w, r, before, task, err, "Recurrence updated successfully")
+}
+
+// StopRecurrence stops the series, the occurrences that already exist are kept
+func (h *TaskHandler) StopRecurrence(w http.ResponseWriter, r *http.Request) {
+	before, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	task, err := h.RecurrenceService.StopSeries(r.Context(), before.ID.Hex())
+	h.writeRecurrenceResponse(w, r, before, task, err, "Recurrence stopped successfully")
+}
+
+// SkipOccurrence deletes this occurrence of the series after generating the following one
+func (h *TaskHandler) SkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+
+	next, err := h.RecurrenceService.SkipOccurrence(r.Context(), task)
+	switch {
+	case errors.Is(err, services.ErrNotRecurring):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrNoBoardForOccurrence):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to skip occurrence. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Service.DeleteTask(r.Context(), task.ID.Hex()); err != nil {
+		http.Error(w, "Unable to delete skipped occurrence. Check Server", http.StatusInternalServerError)
+		return
+	}
+	h.taskChanged(r, task, nil)
+	h.taskChanged(r, nil, next)
+	if err := h.cleanupDeletedTask(r, task); err != nil {
+		http.Error(w, "Unable to delete comments. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":         true,
+		"message":         "Occurrence skipped successfully",
+		"next_occurrence": next,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
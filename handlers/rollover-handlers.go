@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+)
+
+// RolloverBoard moves or copies the unfinished tasks of the board to the target board, or to the
+// next board of the owner, created when there is none, and completes the board
+func (h *BoardHandler) RolloverBoard(w http.ResponseWriter, r *http.Request) {
+	rolloverRequest, ok := r.Context().Value(middlewares.RolloverRequestKey).(models.RolloverRequest)
+	if !ok {
+		http.Error(w, "Unable to process rollover. Check Server", http.StatusInternalServerError)
+		return
+	}
+	source, err := h.Service.GetBoardById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return
+	}
+	if !isBoardMember(r, source) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return
+	}
+	if source.Completed {
+		http.Error(w, services.ErrBoardCompleted.Error(), http.StatusConflict)
+		return
+	}
+
+	var target *models.Board
+	created := false
+	if rolloverRequest.TargetBoardID != nil {
+		target, err = h.Service.GetBoardById(r.Context(), rolloverRequest.TargetBoardID.Hex())
+		if err != nil {
+			http.Error(w, "Target board not found", http.StatusNotFound)
+			return
+		}
+	} else {
+		target, created, err = h.RolloverService.NextBoard(r.Context(), actorID(r), source)
+		if err != nil {
+			http.Error(w, "Unable to find the next board. Check Server", http.StatusInternalServerError)
+			return
+		}
+	}
+	if !isBoardMember(r, target) {
+		http.Error(w, "Rolling tasks over needs membership of both boards", http.StatusForbidden)
+		return
+	}
+
+	mode := rolloverRequest.Mode
+	if mode == "" {
+		mode = models.RolloverMove
+	}
+	board, rolledOver, err := h.RolloverService.Rollover(r.Context(), actorID(r), source, target, mode, isForced(r))
+	switch {
+	case errors.Is(err, services.ErrBoardCompleted), errors.Is(err, services.ErrBoardArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Board is being modified by other requests, try again", http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrRolloverTarget):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Unable to roll over board. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	result := models.RolloverResult{SourceBoard: board, TargetBoard: target, CreatedTarget: created, Results: []models.BulkItemResult{}}
+	for _, task := range rolledOver {
+		result.Results = append(result.Results, bulkItemResult(task.TaskID, task.Task, task.Err))
+	}
+	message := "Board rolled over successfully"
+	if !board.Completed {
+		message = "Some tasks could not be rolled over, the board stays open"
+	}
+
+	response := map[string]interface{}{
+		"success":  board.Completed,
+		"message":  message,
+		"rollover": result,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"todoerbk/middlewares"
+	"todoerbk/services"
+)
+
+type SearchHandler struct {
+	Service *services.SearchService
+}
+
+func NewSearchHandler(service *services.SearchService) *SearchHandler {
+	return &SearchHandler{Service: service}
+}
+
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	userId := r.Context().Value(middlewares.UserIDKey).(string)
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		http.Error(w, "Query param 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	var limit int64
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.ParseInt(rawLimit, 10, 64)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := h.Service.Search(r.Context(), userId, text, limit)
+	if err != nil {
+		http.Error(w, "Unable to search. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Search completed successfully",
+		"results": results,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
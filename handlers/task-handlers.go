@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 	"todoerbk/middlewares"
 	"todoerbk/models"
@@ -13,12 +16,29 @@ import (
 )
 
 type TaskHandler struct {
-	Service      *services.TaskService
-	BoardService *services.BoardService
+	Service             *services.TaskService
+	BoardService        *services.BoardService
+	NotificationService *services.NotificationService
+	CommentService      *services.CommentService
+	AttachmentService   *services.AttachmentService
+	RecurrenceService   *services.RecurrenceService
+	ActivityService     *services.ActivityService
+	WorkLogService      *services.WorkLogService
+	CompletionService   *services.CompletionService
 }
 
-func NewTaskHandler(service *services.TaskService, boardService *services.BoardService) *TaskHandler {
-	return &TaskHandler{Service: service, BoardService: boardService}
+func NewTaskHandler(service *services.TaskService, boardService *services.BoardService, notificationService *services.NotificationService, commentService *services.CommentService, attachmentService *services.AttachmentService, recurrenceService *services.RecurrenceService, activityService *services.ActivityService, workLogService *services.WorkLogService, completionService *services.CompletionService) *TaskHandler {
+	return &TaskHandler{
+		Service:             service,
+		BoardService:        boardService,
+		NotificationService: notificationService,
+		CommentService:      commentService,
+		AttachmentService:   attachmentService,
+		RecurrenceService:   recurrenceService,
+		ActivityService:     activityService,
+		WorkLogService:      workLogService,
+		CompletionService:   completionService,
+	}
 }
 
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -33,6 +53,7 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	now := time.Now().UTC()
 	task.CreatedAt = now
 	task.UpdatedAt = now
+	task.Version = 1
 
 	// Validar que el Board existe
 	_, err := primitive.ObjectIDFromHex(task.BoardID.Hex())
@@ -46,35 +67,85 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Board not found", http.StatusNotFound)
 		return
 	}
+	if rejectArchived(w, board) {
+		return
+	}
 
 	// Establecer valores predeterminados si no están definidos
 	if task.Status == "" {
-		task.Status = models.TODO
+		task.Status = board.InitialStatus()
 	}
 
 	if task.Priority == "" {
 		task.Priority = models.LOW
 	}
 
-	err = h.Service.CreateTask(r.Context(), &task)
+	column, err := workflowColumn(board, task.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	task.Status = column.Status
+	task.StatusCategory = column.Category
+
+	if err := validateAssignees(task, board); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateLabels(task, board); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for i := range task.Checklist {
+		task.Checklist[i].ID = primitive.NewObjectID()
+	}
+	task.Attachments = nil
+	task.CopiedFrom = nil
+	task.RolledOverFrom = nil
+	services.ResetLoggedTime(&task)
+
+	scheduleReminders(&task, nil)
+
+	if task.Recurrence != nil {
+		if err := services.StartSeries(&task, task.Recurrence.Rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.Service.EnterColumn(r.Context(), task.BoardID, task.ID, "", *column, isForced(r), func() error {
+		return h.Service.CreateTask(r.Context(), &task)
+	})
+	if writeWIPLimitError(w, err) {
+		return
+	}
 	if err != nil {
 		http.Error(w, "Unable to create task. Check Server", http.StatusInternalServerError)
 		return
 	}
 
+	h.taskChanged(r, nil, &task)
+	h.notifyNewAssignees(r, task, nil)
+
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Task created successfully",
 		"task":    task,
 	}
+	if warnings := dueDateWarnings(task, board); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
-	tasks, err := h.Service.GetTasks(r.Context())
+	query, _ := r.Context().Value(middlewares.TaskQueryKey).(models.TaskQuery)
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	tasks, nextCursor, err := h.Service.GetTasks(r.Context(), query, page)
 	if err != nil {
 		http.Error(w, "Unable to get tasks. Check Server", http.StatusInternalServerError)
 		return
@@ -85,9 +156,10 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := map[string]interface{}{
-		"success": true,
-		"message": "All tasks retrieved successfully",
-		"tasks":   tasks,
+		"success":     true,
+		"message":     "All tasks retrieved successfully",
+		"tasks":       tasks,
+		"next_cursor": nextCursor,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -109,6 +181,7 @@ func (h *TaskHandler) GetTaskById(w http.ResponseWriter, r *http.Request) {
 		"task":    taskToReturn,
 	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(taskToReturn.Version))
 	w.WriteHeader(http.StatusFound)
 	json.NewEncoder(w).Encode(response)
 }
@@ -125,6 +198,7 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Task to update not found", http.StatusNotFound)
 		return
 	}
+	before := cloneTask(taskToUpdate)
 
 	// Validar que el Board existe
 	_, err = primitive.ObjectIDFromHex(taskUpdateBody.BoardID.Hex())
@@ -138,29 +212,123 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Board not found", http.StatusNotFound)
 		return
 	}
+	if rejectArchived(w, board) {
+		return
+	}
 
-	taskToUpdate.Title = taskUpdateBody.Title
+	// A new board_id moves the task, which needs the user on both boards
+	var source *models.Board
+	changesBoard := taskUpdateBody.BoardID != taskToUpdate.BoardID
+	if changesBoard {
+		source, err = h.BoardService.GetBoardById(r.Context(), taskToUpdate.BoardID.Hex())
+		if err != nil {
+			http.Error(w, "Board of the task not found", http.StatusNotFound)
+			return
+		}
+		if !isBoardMember(r, source) || !isBoardMember(r, board) {
+			http.Error(w, "Moving a task needs membership of both boards", http.StatusForbidden)
+			return
+		}
+	}
+
+	if version, ok := middlewares.IfMatchVersion(r, taskToUpdate.Version); ok {
+		taskToUpdate.Version = version
+	}
+
+	previousStatus, previousCategory := taskToUpdate.Status, taskToUpdate.StatusCategory
+	if changesBoard {
+		services.RetargetTask(taskToUpdate, source, board)
+		previousStatus = ""
+	}
+	status := taskToUpdate.Status
 	if taskUpdateBody.Status != "" {
-		taskToUpdate.Status = taskUpdateBody.Status
+		status = taskUpdateBody.Status
+	}
+	column, err := workflowColumn(board, status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	if (!changesBoard && !checkTransition(w, board, taskToUpdate, column)) || !h.checkBlockers(w, r, taskToUpdate, column) {
+		return
+	}
+
+	taskToUpdate.Title = taskUpdateBody.Title
+	if !changesBoard && column.Status != taskToUpdate.Status {
+		// A task that changes column without a move goes to the end of the new column
+		rank, err := h.Service.RankAtEnd(r.Context(), taskToUpdate.BoardID, column.Status)
+		if err != nil {
+			http.Error(w, "Unable to update task. Check Server", http.StatusInternalServerError)
+			return
+		}
+		taskToUpdate.Rank = rank
+	}
+	taskToUpdate.Status = column.Status
+	taskToUpdate.StatusCategory = column.Category
 	if taskUpdateBody.Priority != "" {
 		taskToUpdate.Priority = taskUpdateBody.Priority
 	}
+	taskToUpdate.OriginalEstimate = taskUpdateBody.OriginalEstimate
+	taskToUpdate.StoryPoints = taskUpdateBody.StoryPoints
+	taskToUpdate.RemainingEstimate = taskUpdateBody.RemainingEstimate
+	previousReminders := taskToUpdate.Reminders
+	taskToUpdate.DueDate = taskUpdateBody.DueDate
+	taskToUpdate.Reminders = taskUpdateBody.Reminders
+	scheduleReminders(taskToUpdate, previousReminders)
+
+	previousAssignees := taskToUpdate.Assignees
+	taskToUpdate.Assignees = taskUpdateBody.Assignees
+	if err := validateAssignees(*taskToUpdate, board); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	taskToUpdate.LabelIDs = taskUpdateBody.LabelIDs
+	if err := validateLabels(*taskToUpdate, board); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	now := time.Now().UTC()
 	taskToUpdate.UpdatedAt = now
 
-	err = h.Service.UpdateTask(r.Context(), taskId, *taskToUpdate)
+	err = h.Service.EnterColumn(r.Context(), taskToUpdate.BoardID, taskToUpdate.ID, previousStatus, *column, isForced(r), func() error {
+		if changesBoard {
+			return h.Service.MoveTaskToBoard(r.Context(), taskToUpdate, source.OwnerID == board.OwnerID)
+		}
+		return h.Service.UpdateTask(r.Context(), taskId, taskToUpdate)
+	})
+	if writeWIPLimitError(w, err) {
+		return
+	}
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Task was modified by another request", http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Task to update not found", http.StatusNotFound)
 		return
 	}
 
+	if changesBoard {
+		h.moveTaskContents(r, taskToUpdate.ID, board.ID)
+	}
+	h.taskChanged(r, before, taskToUpdate)
+	h.notifyNewAssignees(r, *taskToUpdate, previousAssignees)
+	next := h.completeOccurrence(r, taskToUpdate, previousCategory)
+
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Task updated successfully",
 		"task":    taskToUpdate,
 	}
+	if next != nil {
+		response["next_occurrence"] = next
+	}
+	if warnings := dueDateWarnings(*taskToUpdate, board); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(taskToUpdate.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
@@ -172,12 +340,23 @@ func (h *TaskHandler) DeleteTaskByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Task to delete not found", http.StatusNotFound)
 		return
 	}
-	err = h.Service.DeleteTask(r.Context(), taskToDelete.ID.Hex())
+	version, _ := middlewares.IfMatchVersion(r, taskToDelete.Version)
+	err = h.Service.DeleteTaskAtVersion(r.Context(), taskToDelete.ID.Hex(), version)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Task was modified by another request", http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Task to delete not found", http.StatusNotFound)
 		return
 	}
 
+	h.taskChanged(r, taskToDelete, nil)
+	if err := h.cleanupDeletedTask(r, taskToDelete); err != nil {
+		http.Error(w, "Unable to delete comments. Check Server", http.StatusInternalServerError)
+		return
+	}
+
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Task with id " + taskId + " deleted successfully",
@@ -186,3 +365,200 @@ func (h *TaskHandler) DeleteTaskByID(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// MoveTask places the task in a status column between two neighbors, as a drag and drop does
+func (h *TaskHandler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	moveRequest, ok := r.Context().Value(middlewares.MoveTaskRequestKey).(models.MoveTaskRequest)
+	if !ok {
+		http.Error(w, "Unable to process move. Check Server", http.StatusInternalServerError)
+		return
+	}
+	taskToMove, board, ok := loadTaskForMember(w, r, h.Service, h.BoardService)
+	if !ok {
+		return
+	}
+	taskId := taskToMove.ID.Hex()
+	column, err := workflowColumn(board, moveRequest.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !checkTransition(w, board, taskToMove, column) || !h.checkBlockers(w, r, taskToMove, column) {
+		return
+	}
+
+	var task *models.Task
+	err = h.Service.EnterColumn(r.Context(), taskToMove.BoardID, taskToMove.ID, taskToMove.Status, *column, isForced(r), func() error {
+		task, err = h.Service.MoveTask(r.Context(), taskId, moveRequest, *column)
+		return err
+	})
+	if writeWIPLimitError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidMove):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to move task. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	h.taskChanged(r, taskToMove, task)
+	next := h.completeOccurrence(r, task, taskToMove.StatusCategory)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Task moved successfully",
+		"task":    task,
+	}
+	if next != nil {
+		response["next_occurrence"] = next
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// scheduleReminders computes when each reminder fires from the due date.
+// Reminders already sent for the same moment keep their sent date so they are not sent twice.
+func scheduleReminders(task *models.Task, previous []models.Reminder) {
+	if task.DueDate == nil {
+		task.Reminders = nil
+		return
+	}
+
+	seen := map[int]bool{}
+	reminders := []models.Reminder{}
+	for _, reminder := range task.Reminders {
+		if seen[reminder.OffsetMinutes] {
+			continue
+		}
+		seen[reminder.OffsetMinutes] = true
+
+		reminder.RemindAt = task.DueDate.Add(-time.Duration(reminder.OffsetMinutes) * time.Minute).UTC()
+		reminder.SentAt = nil
+		for _, old := range previous {
+			if old.OffsetMinutes == reminder.OffsetMinutes && old.RemindAt.Equal(reminder.RemindAt) {
+				reminder.SentAt = old.SentAt
+			}
+		}
+		reminders = append(reminders, reminder)
+	}
+	task.Reminders = reminders
+}
+
+// dueDateWarnings reports a due date outside the days covered by the board, which is allowed but suspicious
+func dueDateWarnings(task models.Task, board *models.Board) []string {
+	if task.DueDate == nil {
+		return nil
+	}
+
+	day := task.DueDate.UTC().Truncate(24 * time.Hour)
+	if day.Before(board.FromDate.UTC().Truncate(24*time.Hour)) || day.After(board.ToDate.UTC().Truncate(24*time.Hour)) {
+		return []string{"Due date is outside the board range " + board.FromDate.Format("2006-01-02") + " - " + board.ToDate.Format("2006-01-02")}
+	}
+	return nil
+}
+
+// validateAssignees checks that every assignee, of the task or its checklist, is the owner or a member of the board
+func validateAssignees(task models.Task, board *models.Board) error {
+	for _, assignee := range task.Assignees {
+		if !board.IsMember(assignee) {
+			return errors.New("assignee " + assignee.Hex() + " is not a member of the board")
+		}
+	}
+	for _, item := range task.Checklist {
+		if item.AssigneeID != nil && !board.IsMember(*item.AssigneeID) {
+			return errors.New("assignee " + item.AssigneeID.Hex() + " is not a member of the board")
+		}
+	}
+	return nil
+}
+
+// workflowColumn returns the column of the board workflow for the status, case insensitive
+func workflowColumn(board *models.Board, status models.TaskStatus) (*models.WorkflowColumn, error) {
+	column, ok := board.Column(services.NormalizeStatus(status))
+	if !ok {
+		statuses := []string{}
+		for _, s := range board.Statuses() {
+			statuses = append(statuses, string(s))
+		}
+		return nil, errors.New("Invalid Task Status. < field: status, value: " + strings.Join(statuses, ", ") + " >")
+	}
+	return column, nil
+}
+
+// checkTransition answers 409 and returns false when the workflow does not allow the task to reach the column
+func checkTransition(w http.ResponseWriter, board *models.Board, task *models.Task, column *models.WorkflowColumn) bool {
+	if board.CanTransition(task.Status, column.Status) {
+		return true
+	}
+	http.Error(w, "The board workflow does not allow moving a task from "+string(task.Status)+" to "+string(column.Status), http.StatusConflict)
+	return false
+}
+
+// writeWIPLimitError answers 409 and returns true when err comes from the WIP limit check
+func writeWIPLimitError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, services.ErrColumnBusy) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return true
+	}
+	var wipErr *services.WIPLimitError
+	if !errors.As(err, &wipErr) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	response := map[string]interface{}{
+		"success": false,
+		"message": "The WIP limit of the column would be exceeded. Move other tasks first or retry with ?force=true",
+		"errors":  []string{wipErr.Error()},
+	}
+	json.NewEncoder(w).Encode(response)
+	return true
+}
+
+// validateLabels checks that every label of the task is in the catalog of the board
+func validateLabels(task models.Task, board *models.Board) error {
+	for _, labelID := range task.LabelIDs {
+		if _, ok := board.Label(labelID); !ok {
+			return errors.New("label " + labelID.Hex() + " is not in the board catalog")
+		}
+	}
+	return nil
+}
+
+// notifyNewAssignees tells the users added to the task, except the one who made the change
+func (h *TaskHandler) notifyNewAssignees(r *http.Request, task models.Task, previous []primitive.ObjectID) {
+	actorID, _ := middlewares.GetUserID(r)
+	for _, assignee := range task.Assignees {
+		if assignee.Hex() == actorID || containsObjectID(previous, assignee) {
+			continue
+		}
+		taskID, boardID := task.ID, task.BoardID
+		err := h.NotificationService.Notify(r.Context(), &models.Notification{
+			UserID:  assignee,
+			Type:    models.TaskAssignedNotification,
+			Message: "You were assigned to task \"" + task.Title + "\"",
+			TaskID:  &taskID,
+			BoardID: &boardID,
+		})
+		if err != nil {
+			log.Printf("Error notifying assignee %s of task %s: %v", assignee.Hex(), task.ID.Hex(), err)
+		}
+	}
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+)
+
+type TemplateHandler struct {
+	Service         *services.TemplateService
+	BoardService    *services.BoardService
+	ActivityService *services.ActivityService
+}
+
+func NewTemplateHandler(service *services.TemplateService, boardService *services.BoardService, activityService *services.ActivityService) *TemplateHandler {
+	return &TemplateHandler{Service: service, BoardService: boardService, ActivityService: activityService}
+}
+
+// loadOwnTemplate returns the template of the route when it belongs to the user
+func (h *TemplateHandler) loadOwnTemplate(w http.ResponseWriter, r *http.Request) (*models.BoardTemplate, bool) {
+	template, err := h.Service.GetTemplateById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return nil, false
+	}
+	if template.OwnerID != actorID(r) {
+		http.Error(w, "Only the owner can use this template", http.StatusForbidden)
+		return nil, false
+	}
+	return template, true
+}
+
+// loadMemberBoard returns the board of the route when the user is a member of it
+func (h *TemplateHandler) loadMemberBoard(w http.ResponseWriter, r *http.Request) (*models.Board, bool) {
+	board, err := h.BoardService.GetBoardById(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !isBoardMember(r, board) {
+		http.Error(w, "You are not a member of this board", http.StatusForbidden)
+		return nil, false
+	}
+	return board, true
+}
+
+// writeCreatedBoard records the creation of the board and its tasks and returns them
+func (h *TemplateHandler) writeCreatedBoard(w http.ResponseWriter, r *http.Request, board *models.Board, tasks []models.Task, err error, message string) {
+	if err != nil {
+		http.Error(w, "Unable to create board. Check Server", http.StatusInternalServerError)
+		return
+	}
+	recordActivity(r, h.ActivityService, services.BoardActivity(actorID(r), nil, board))
+	for i := range tasks {
+		recordActivity(r, h.ActivityService, services.TaskActivity(actorID(r), nil, &tasks[i]))
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"board":   board,
+		"tasks":   tasks,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SaveBoardAsTemplate saves the board as a template of the user
+func (h *TemplateHandler) SaveBoardAsTemplate(w http.ResponseWriter, r *http.Request) {
+	templateRequest, ok := r.Context().Value(middlewares.BoardTemplateRequestKey).(models.BoardTemplateRequest)
+	if !ok {
+		http.Error(w, "Unable to process template. Check Server", http.StatusInternalServerError)
+		return
+	}
+	board, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := h.Service.TemplateFromBoard(r.Context(), board, actorID(r), templateRequest)
+	if err == nil {
+		err = h.Service.CreateTemplate(r.Context(), template)
+	}
+	if err != nil {
+		http.Error(w, "Unable to create template. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":  true,
+		"message":  "Template created successfully",
+		"template": template,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TemplateHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	templates, nextCursor, err := h.Service.GetTemplatesByOwnerId(r.Context(), actorID(r), page)
+	if err != nil {
+		http.Error(w, "Unable to get templates. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"message":     "Templates retrieved successfully",
+		"templates":   templates,
+		"next_cursor": nextCursor,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TemplateHandler) GetTemplateById(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.loadOwnTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":  true,
+		"message":  "Template retrieved successfully",
+		"template": template,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.loadOwnTemplate(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteTemplate(r.Context(), template.ID); err != nil {
+		http.Error(w, "Unable to delete template. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Template with id " + template.ID.Hex() + " deleted successfully",
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateBoardFromTemplate creates a board of the user from the template, with its dates shifted to the start date
+func (h *TemplateHandler) CreateBoardFromTemplate(w http.ResponseWriter, r *http.Request) {
+	boardRequest, ok := r.Context().Value(middlewares.BoardFromTemplateRequestKey).(models.BoardFromTemplateRequest)
+	if !ok {
+		http.Error(w, "Unable to process board. Check Server", http.StatusInternalServerError)
+		return
+	}
+	template, ok := h.loadOwnTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	board, tasks, err := h.Service.CreateBoardFromTemplate(r.Context(), template, actorID(r), boardRequest)
+	h.writeCreatedBoard(w, r, board, tasks, err, "Board created from template successfully")
+}
+
+// CloneBoard copies the board for the user, optionally with its tasks
+func (h *TemplateHandler) CloneBoard(w http.ResponseWriter, r *http.Request) {
+	cloneRequest, ok := r.Context().Value(middlewares.BoardCloneRequestKey).(models.BoardCloneRequest)
+	if !ok {
+		http.Error(w, "Unable to process clone. Check Server", http.StatusInternalServerError)
+		return
+	}
+	source, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+
+	board, tasks, err := h.Service.CloneBoard(r.Context(), source, actorID(r), cloneRequest)
+	h.writeCreatedBoard(w, r, board, tasks, err, "Board cloned successfully")
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// itemError is why an operation failed for one task, with the status to answer
+type itemError struct {
+	status  int
+	message string
+}
+
+func (e *itemError) Error() string {
+	return e.message
+}
+
+// loadTransferTarget returns the board tasks are moved or copied to, the user must be a member of it
+func (h *TaskHandler) loadTransferTarget(w http.ResponseWriter, r *http.Request, boardID primitive.ObjectID) (*models.Board, bool) {
+	board, err := h.BoardService.GetBoardById(r.Context(), boardID.Hex())
+	if err != nil || board == nil {
+		http.Error(w, "Board not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !isBoardMember(r, board) {
+		http.Error(w, "You are not a member of the target board", http.StatusForbidden)
+		return nil, false
+	}
+	if rejectArchived(w, board) {
+		return nil, false
+	}
+	return board, true
+}
+
+// isBoardMember tells if the user of the request owns the board or was added to it
+func isBoardMember(r *http.Request, board *models.Board) bool {
+	userID, _ := middlewares.GetUserID(r)
+	userObjectID, _ := primitive.ObjectIDFromHex(userID)
+	return board.IsMember(userObjectID)
+}
+
+// transferTask moves the task to the target board, or copies it there when copy is true.
+// A move keeps the task id, comments, attachments and checklist; a copy starts a new task that
+// remembers the original in copied_from. A move expects the version of the If-Match header, when given.
+func (h *TaskHandler) transferTask(r *http.Request, taskID primitive.ObjectID, target *models.Board, status models.TaskStatus, copy bool) (*models.Task, error) {
+	ctx := r.Context()
+	task, err := h.Service.GetTaskById(ctx, taskID.Hex())
+	if err != nil {
+		return nil, &itemError{http.StatusNotFound, "Task not found"}
+	}
+	source, err := h.BoardService.GetBoardById(ctx, task.BoardID.Hex())
+	if err != nil {
+		return nil, &itemError{http.StatusNotFound, "Board of the task not found"}
+	}
+	if !isBoardMember(r, source) {
+		return nil, &itemError{http.StatusForbidden, "You are not a member of the board of the task"}
+	}
+	// Copying out of an archived board only reads the task
+	if !copy && source.Archived {
+		return nil, &itemError{http.StatusConflict, services.ErrBoardArchived.Error()}
+	}
+	if !copy && task.BoardID == target.ID {
+		return nil, &itemError{http.StatusBadRequest, "The task is already on this board"}
+	}
+
+	result := *task
+	result.Checklist = append([]models.ChecklistItem(nil), task.Checklist...)
+	services.RetargetTask(&result, source, target)
+	if status != "" {
+		result.Status = status
+	}
+	column, err := workflowColumn(target, result.Status)
+	if err != nil {
+		return nil, &itemError{http.StatusBadRequest, err.Error()}
+	}
+	result.Status = column.Status
+	result.StatusCategory = column.Category
+	now := time.Now().UTC()
+	result.UpdatedAt = now
+	force := isForced(r)
+
+	if copy {
+		services.PrepareCopy(&result, task.ID, now)
+		scheduleReminders(&result, nil)
+
+		err = h.Service.EnterColumn(ctx, target.ID, result.ID, "", *column, force, func() error {
+			return h.Service.CreateTask(ctx, &result)
+		})
+		if err != nil {
+			return nil, transferWriteError(err, false)
+		}
+		h.taskChanged(r, nil, &result)
+		h.notifyNewAssignees(r, result, nil)
+		return &result, nil
+	}
+
+	version, versioned := middlewares.IfMatchVersion(r, task.Version)
+	result.Version = version
+	keepDependencies := source.OwnerID == target.OwnerID
+	if keepDependencies && column.Category != models.CategoryTodo && !force {
+		blockers, err := h.Service.GetUnfinishedBlockers(ctx, &result)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 {
+			return nil, &itemError{http.StatusConflict, "Task has unfinished blockers. Finish them first or retry with ?force=true"}
+		}
+	}
+
+	err = h.Service.EnterColumn(ctx, target.ID, result.ID, "", *column, force, func() error {
+		return h.Service.MoveTaskToBoard(ctx, &result, keepDependencies)
+	})
+	if err != nil {
+		return nil, transferWriteError(err, versioned)
+	}
+	h.moveTaskContents(r, result.ID, target.ID)
+	h.taskChanged(r, task, &result)
+	h.completeOccurrence(r, &result, task.StatusCategory)
+	return &result, nil
+}
+
+// transferWriteError keeps WIP limit errors as they are and turns version conflicts into transfer errors
+func transferWriteError(err error, ifMatch bool) error {
+	switch {
+	case errors.Is(err, services.ErrVersionMismatch) && ifMatch:
+		return &itemError{http.StatusPreconditionFailed, "Task was modified by another request"}
+	case errors.Is(err, services.ErrVersionMismatch):
+		return &itemError{http.StatusConflict, "Task is being modified by other requests, try again"}
+	}
+	return err
+}
+
+func writeTransferError(w http.ResponseWriter, err error) {
+	if writeWIPLimitError(w, err) {
+		return
+	}
+	var itemErr *itemError
+	if errors.As(err, &itemErr) {
+		http.Error(w, itemErr.message, itemErr.status)
+		return
+	}
+	http.Error(w, "Unable to transfer task. Check Server", http.StatusInternalServerError)
+}
+
+// TransferTask moves the task to another board
+func (h *TaskHandler) TransferTask(w http.ResponseWriter, r *http.Request) {
+	h.transferOne(w, r, false)
+}
+
+// CopyTask copies the task to a board, which can be its own
+func (h *TaskHandler) CopyTask(w http.ResponseWriter, r *http.Request) {
+	h.transferOne(w, r, true)
+}
+
+func (h *TaskHandler) transferOne(w http.ResponseWriter, r *http.Request, copy bool) {
+	transferRequest, ok := r.Context().Value(middlewares.TaskTransferRequestKey).(models.TaskTransferRequest)
+	if !ok {
+		http.Error(w, "Unable to process transfer. Check Server", http.StatusInternalServerError)
+		return
+	}
+	target, ok := h.loadTransferTarget(w, r, transferRequest.BoardID)
+	if !ok {
+		return
+	}
+
+	taskID, _ := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	task, err := h.transferTask(r, taskID, target, transferRequest.Status, copy)
+	if err != nil {
+		writeTransferError(w, err)
+		return
+	}
+
+	message, status := "Task moved successfully", http.StatusOK
+	if copy {
+		message, status = "Task copied successfully", http.StatusCreated
+	}
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"task":    task,
+	}
+	if warnings := dueDateWarnings(*task, target); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// TransferTasks moves several tasks to another board, each one succeeds or fails on its own
+func (h *TaskHandler) TransferTasks(w http.ResponseWriter, r *http.Request) {
+	h.transferMany(w, r, false)
+}
+
+// CopyTasks copies several tasks to a board, each one succeeds or fails on its own
+func (h *TaskHandler) CopyTasks(w http.ResponseWriter, r *http.Request) {
+	h.transferMany(w, r, true)
+}
+
+func (h *TaskHandler) transferMany(w http.ResponseWriter, r *http.Request, copy bool) {
+	transferRequest, ok := r.Context().Value(middlewares.BulkTaskTransferRequestKey).(models.BulkTaskTransferRequest)
+	if !ok {
+		http.Error(w, "Unable to process transfer. Check Server", http.StatusInternalServerError)
+		return
+	}
+	target, ok := h.loadTransferTarget(w, r, transferRequest.BoardID)
+	if !ok {
+		return
+	}
+
+	results := []models.BulkItemResult{}
+	for _, taskID := range uniqueObjectIDs(transferRequest.TaskIDs) {
+		task, err := h.transferTask(r, taskID, target, transferRequest.Status, copy)
+		results = append(results, bulkItemResult(taskID, task, err))
+	}
+
+	message := "Tasks moved"
+	if copy {
+		message = "Tasks copied"
+	}
+	writeBulkResults(w, message, results)
+}
+
+// moveTaskContents moves the comments and work logs of a task that changed board
+func (h *TaskHandler) moveTaskContents(r *http.Request, taskID, boardID primitive.ObjectID) {
+	if err := h.CommentService.MoveCommentsToBoard(r.Context(), taskID, boardID); err != nil {
+		log.Printf("Error moving comments of task %s to board %s: %v", taskID.Hex(), boardID.Hex(), err)
+	}
+	if err := h.WorkLogService.MoveWorkLogsToBoard(r.Context(), taskID, boardID); err != nil {
+		log.Printf("Error moving work logs of task %s to board %s: %v", taskID.Hex(), boardID.Hex(), err)
+	}
+}
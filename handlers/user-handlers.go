@@ -7,16 +7,22 @@ import (
 	"todoerbk/middlewares"
 	"todoerbk/models"
 	"todoerbk/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserHandler struct {
-	Service      *services.UserService
-	BoardService *services.BoardService
-	TaskService  *services.TaskService
+	Service           *services.UserService
+	BoardService      *services.BoardService
+	TaskService       *services.TaskService
+	CommentService    *services.CommentService
+	AttachmentService *services.AttachmentService
+	WorkLogService    *services.WorkLogService
+	TemplateService   *services.TemplateService
 }
 
-func NewUserHandler(service *services.UserService, boardService *services.BoardService, taskService *services.TaskService) *UserHandler {
-	return &UserHandler{Service: service, BoardService: boardService, TaskService: taskService}
+func NewUserHandler(service *services.UserService, boardService *services.BoardService, taskService *services.TaskService, commentService *services.CommentService, attachmentService *services.AttachmentService, workLogService *services.WorkLogService, templateService *services.TemplateService) *UserHandler {
+	return &UserHandler{Service: service, BoardService: boardService, TaskService: taskService, CommentService: commentService, AttachmentService: attachmentService, WorkLogService: workLogService, TemplateService: templateService}
 }
 
 func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
@@ -73,32 +79,46 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//delete all boards iterating over the boards
-	boards, err := h.BoardService.GetBoardsByOwnerID(r.Context(), userID)
+	//delete all boards iterating over the board ids
+	boardIDs, err := h.BoardService.GetBoardIDsByOwnerID(r.Context(), userID)
 	if err != nil {
 		http.Error(w, "Unable to delete boards. Check Server", http.StatusInternalServerError)
 		return
 	}
-	for _, board := range boards {
-		//delete all tasks associated with the board
-		tasks, err := h.TaskService.GetTasksByBoardId(r.Context(), board.ID.Hex())
+	for _, boardID := range boardIDs {
+		//delete all tasks associated with the board and the blobs of their attachments
+		attachments, err := h.TaskService.GetAttachmentsByBoardId(r.Context(), boardID.Hex())
+		if err != nil {
+			http.Error(w, "Unable to delete tasks. Check Server", http.StatusInternalServerError)
+			return
+		}
+		err = h.TaskService.DeleteTasksByBoardId(r.Context(), boardID.Hex())
 		if err != nil {
 			http.Error(w, "Unable to delete tasks. Check Server", http.StatusInternalServerError)
 			return
 		}
-		for _, task := range tasks {
-			err = h.TaskService.DeleteTask(r.Context(), task.ID.Hex())
-			if err != nil {
-				http.Error(w, "Unable to delete tasks. Check Server", http.StatusInternalServerError)
-				return
-			}
+		h.AttachmentService.DeleteBlobs(r.Context(), attachments)
+		err = h.CommentService.DeleteCommentsByBoardId(r.Context(), boardID.Hex())
+		if err != nil {
+			http.Error(w, "Unable to delete comments. Check Server", http.StatusInternalServerError)
+			return
+		}
+		err = h.WorkLogService.DeleteWorkLogsByBoardId(r.Context(), boardID.Hex())
+		if err != nil {
+			http.Error(w, "Unable to delete work logs. Check Server", http.StatusInternalServerError)
+			return
 		}
-		err = h.BoardService.DeleteBoard(r.Context(), board.ID.Hex())
+		err = h.BoardService.DeleteBoard(r.Context(), boardID.Hex())
 		if err != nil {
 			http.Error(w, "Unable to delete boards. Check Server", http.StatusInternalServerError)
 			return
 		}
 	}
+	err = h.TemplateService.DeleteTemplatesByOwnerId(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Unable to delete templates. Check Server", http.StatusInternalServerError)
+		return
+	}
 
 	response := map[string]interface{}{
 		"success": true,
@@ -141,3 +161,52 @@ func (h *UserHandler) ActivateUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetMyTasks returns the tasks assigned to the caller across boards, grouped by board
+func (h *UserHandler) GetMyTasks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.UserIDKey).(string)
+	query, _ := r.Context().Value(middlewares.TaskQueryKey).(models.TaskQuery)
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+
+	tasks, nextCursor, err := h.TaskService.GetTasksByAssignee(r.Context(), userID, query, page)
+	if err != nil {
+		http.Error(w, "Unable to get tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	var boardIDs []primitive.ObjectID
+	groupIndex := map[primitive.ObjectID]int{}
+	groups := []models.BoardTasks{}
+	for _, task := range tasks {
+		index, ok := groupIndex[task.BoardID]
+		if !ok {
+			index = len(groups)
+			groupIndex[task.BoardID] = index
+			groups = append(groups, models.BoardTasks{Tasks: []models.Task{}})
+			boardIDs = append(boardIDs, task.BoardID)
+		}
+		groups[index].Tasks = append(groups[index].Tasks, task)
+	}
+
+	if len(boardIDs) > 0 {
+		boards, err := h.BoardService.GetBoardsByIDs(r.Context(), boardIDs)
+		if err != nil {
+			http.Error(w, "Unable to get boards. Check Server", http.StatusInternalServerError)
+			return
+		}
+		for _, board := range boards {
+			groups[groupIndex[board.ID]].Board = board
+		}
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"message":     "Assigned tasks retrieved successfully",
+		"boards":      groups,
+		"next_cursor": nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
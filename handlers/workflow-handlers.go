@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UpdateWorkflow replaces the columns of the board. A column can only be removed once it has no tasks.
+func (h *BoardHandler) UpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflowRequest, ok := r.Context().Value(middlewares.WorkflowRequestKey).(models.WorkflowRequest)
+	if !ok {
+		http.Error(w, "Unable to process workflow. Check Server", http.StatusInternalServerError)
+		return
+	}
+	workflow, err := services.NormalizeWorkflow(workflowRequest.Columns)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	board, ok := h.loadMemberBoard(w, r)
+	if !ok {
+		return
+	}
+
+	errs, err := h.stranded(r, board.ID, workflow)
+	if err != nil {
+		http.Error(w, "Unable to count board tasks. Check Server", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		writeStrandedError(w, errs)
+		return
+	}
+
+	before := board
+	board, err = h.Service.SetWorkflow(r.Context(), before.ID.Hex(), workflow)
+	if errors.Is(err, services.ErrVersionMismatch) {
+		http.Error(w, "Board is being modified by other requests, try again", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Unable to update workflow. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	// a task may have moved into a removed column between the count and the write,
+	// so count again and put the previous workflow back when that happened
+	errs, err = h.stranded(r, board.ID, workflow)
+	if err == nil && len(errs) > 0 {
+		_, err = h.Service.SetWorkflow(r.Context(), before.ID.Hex(), before.Workflow)
+		if err == nil {
+			writeStrandedError(w, errs)
+			return
+		}
+	}
+	if err != nil {
+		http.Error(w, "Unable to update workflow. Check Server", http.StatusInternalServerError)
+		return
+	}
+	if err := h.TaskService.SyncStatusCategories(r.Context(), board.ID, board.Workflow); err != nil {
+		http.Error(w, "Unable to update task categories. Check Server", http.StatusInternalServerError)
+		return
+	}
+	h.recordBoardActivity(r, before, board)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Workflow updated successfully",
+		"board":   board,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(board.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// stranded describes the tasks of the board whose status has no column in the workflow
+func (h *BoardHandler) stranded(r *http.Request, boardID primitive.ObjectID, workflow []models.WorkflowColumn) ([]string, error) {
+	counts, err := h.TaskService.CountTasksByStatus(r.Context(), boardID)
+	if err != nil {
+		return nil, err
+	}
+	kept := models.Board{Workflow: workflow}
+	errs := []string{}
+	for status, count := range counts {
+		if _, ok := kept.Column(status); !ok && count > 0 {
+			errs = append(errs, strconv.Itoa(count)+" tasks are still in status "+string(status))
+		}
+	}
+	return errs, nil
+}
+
+func writeStrandedError(w http.ResponseWriter, errs []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	response := map[string]interface{}{
+		"success": false,
+		"message": "Move the tasks out of the removed columns first",
+		"errors":  errs,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"todoerbk/middlewares"
+	"todoerbk/models"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+)
+
+type WorkLogHandler struct {
+	Service      *services.WorkLogService
+	TaskService  *services.TaskService
+	BoardService *services.BoardService
+}
+
+func NewWorkLogHandler(service *services.WorkLogService, taskService *services.TaskService, boardService *services.BoardService) *WorkLogHandler {
+	return &WorkLogHandler{Service: service, TaskService: taskService, BoardService: boardService}
+}
+
+func writeLoggedWork(w http.ResponseWriter, workLog *models.WorkLog, task *models.Task, err error, message string, status int) {
+	switch {
+	case errors.Is(err, services.ErrNoTimer):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to log work. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":  true,
+		"message":  message,
+		"work_log": workLog,
+		"task":     task,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartTimer starts the timer of the user on the task, a user has one running timer at most
+func (h *WorkLogHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+
+	timer, err := h.Service.StartTimer(r.Context(), actorID(r), task.ID)
+	if errors.Is(err, services.ErrTimerRunning) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		response := map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+			"timer":   timer,
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Unable to start timer. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Timer started successfully",
+		"timer":   timer,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// StopTimer stops the timer of the user on the task and logs the time it ran
+func (h *WorkLogHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+
+	workLog, updated, err := h.Service.StopTimer(r.Context(), actorID(r), task)
+	writeLoggedWork(w, workLog, updated, err, "Timer stopped successfully", http.StatusOK)
+}
+
+// GetMyTimer returns the running timer of the user, null when there is none
+func (h *WorkLogHandler) GetMyTimer(w http.ResponseWriter, r *http.Request) {
+	timer, err := h.Service.GetTimer(r.Context(), actorID(r))
+	if err != nil {
+		http.Error(w, "Unable to get timer. Check Server", http.StatusInternalServerError)
+		return
+	}
+	// A timer left on a deleted task is not running anymore
+	if timer != nil {
+		if _, err := h.TaskService.GetTaskById(r.Context(), timer.TaskID.Hex()); err != nil {
+			timer = nil
+		}
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Timer retrieved successfully",
+		"timer":   timer,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// LogWork logs time spent on the task by hand
+func (h *WorkLogHandler) LogWork(w http.ResponseWriter, r *http.Request) {
+	workLogRequest, ok := r.Context().Value(middlewares.WorkLogRequestKey).(models.WorkLogRequest)
+	if !ok {
+		http.Error(w, "Unable to process work log. Check Server", http.StatusInternalServerError)
+		return
+	}
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+
+	startedAt := time.Now().UTC().Add(-time.Duration(workLogRequest.Minutes) * time.Minute)
+	if workLogRequest.StartedAt != nil {
+		startedAt = workLogRequest.StartedAt.UTC()
+	}
+	workLog := &models.WorkLog{
+		TaskID:    task.ID,
+		BoardID:   task.BoardID,
+		UserID:    actorID(r),
+		StartedAt: startedAt,
+		Minutes:   workLogRequest.Minutes,
+		Note:      workLogRequest.Note,
+		Source:    models.WorkLogManual,
+	}
+	updated, err := h.Service.LogWork(r.Context(), workLog, workLogRequest.RemainingEstimate)
+	writeLoggedWork(w, workLog, updated, err, "Work logged successfully", http.StatusCreated)
+}
+
+func (h *WorkLogHandler) GetWorkLogs(w http.ResponseWriter, r *http.Request) {
+	task, _, ok := loadTaskForMember(w, r, h.TaskService, h.BoardService)
+	if !ok {
+		return
+	}
+	page, _ := r.Context().Value(middlewares.PageRequestKey).(models.PageRequest)
+	workLogs, nextCursor, err := h.Service.GetWorkLogsByTaskId(r.Context(), task.ID, page)
+	if err != nil {
+		http.Error(w, "Unable to get work logs. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":     true,
+		"message":     "Work logs retrieved successfully",
+		"work_logs":   workLogs,
+		"next_cursor": nextCursor,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteWorkLog removes a work log of the user and its time from the task
+func (h *WorkLogHandler) DeleteWorkLog(w http.ResponseWriter, r *http.Request) {
+	workLog, err := h.Service.GetWorkLogById(r.Context(), mux.Vars(r)["workLogId"])
+	if err != nil || workLog.TaskID.Hex() != mux.Vars(r)["id"] {
+		http.Error(w, "Work log not found", http.StatusNotFound)
+		return
+	}
+	if workLog.UserID != actorID(r) {
+		http.Error(w, "Only the user who logged the work can delete it", http.StatusForbidden)
+		return
+	}
+
+	task, err := h.Service.DeleteWorkLog(r.Context(), workLog)
+	switch {
+	case errors.Is(err, services.ErrWorkLogNotFound):
+		http.Error(w, "Work log not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrVersionMismatch):
+		http.Error(w, "Task is being modified by other requests, try again", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Unable to delete work log. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Work log deleted successfully",
+		"task":    task,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", middlewares.ETag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMyTimesheet returns the work the user logged on a range of days, as JSON or as a CSV file
+func (h *WorkLogHandler) GetMyTimesheet(w http.ResponseWriter, r *http.Request) {
+	query, _ := r.Context().Value(middlewares.TimesheetQueryKey).(models.TimesheetQuery)
+	timesheet, err := h.Service.GetTimesheet(r.Context(), actorID(r), query)
+	if err != nil {
+		http.Error(w, "Unable to get timesheet. Check Server", http.StatusInternalServerError)
+		return
+	}
+
+	if query.CSV {
+		writeTimesheetCSV(w, timesheet)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"message":   "Timesheet retrieved successfully",
+		"timesheet": timesheet,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeTimesheetCSV(w http.ResponseWriter, timesheet *models.Timesheet) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="timesheet-`+timesheet.From+`-`+timesheet.To+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "started_at", "minutes", "task_id", "task", "board_id", "board", "source", "note"})
+	for _, entry := range timesheet.Entries {
+		workLog := entry.WorkLog
+		writer.Write([]string{
+			workLog.StartedAt.UTC().Format("2006-01-02"),
+			workLog.StartedAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(workLog.Minutes),
+			workLog.TaskID.Hex(),
+			entry.TaskTitle,
+			workLog.BoardID.Hex(),
+			entry.BoardTitle,
+			string(workLog.Source),
+			workLog.Note,
+		})
+	}
+	writer.Flush()
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"todoerbk/database"
 	"todoerbk/handlers"
@@ -15,6 +17,7 @@ import (
 	gorillaHandlers "github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
@@ -37,37 +40,128 @@ func main() {
 	db, client, ctx, cancel := database.SetupMongoDB(mongoURL)
 	defer database.CloseConnection(client, ctx, cancel)
 
-	boardCollection := db.Collection("boards")
-	taskCollection := db.Collection("tasks")
-	userCollection := db.Collection("users")
+	boardCollection := db.Collection(database.BoardsCollection)
+	taskCollection := db.Collection(database.TasksCollection)
+	userCollection := db.Collection(database.UsersCollection)
+	notificationCollection := db.Collection(database.NotificationsCollection)
+	commentCollection := db.Collection(database.CommentsCollection)
+	workLogCollection := db.Collection(database.WorkLogsCollection)
+	timerCollection := db.Collection(database.TimersCollection)
+	templateCollection := db.Collection(database.TemplatesCollection)
+	attachmentUsageCollection := db.Collection(database.AttachmentUsageCollection)
+	// Los valores de los cambios se leen como mapas para devolverlos como objetos JSON
+	activityCollection := db.Collection(database.ActivityCollection, options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true}))
 
 	boardService := services.NewBoardService(boardCollection)
 	taskService := services.NewTaskService(taskCollection)
 	userService := services.NewUserService(userCollection)
-	authService := services.NewAuthService(userService)
+	mailer := services.NewMailer()
+	authService := services.NewAuthService(userService, mailer)
+	searchService := services.NewSearchService(boardService, taskService)
+	notificationService := services.NewNotificationService(notificationCollection, userService, mailer)
+	commentService := services.NewCommentService(commentCollection, userService)
+	activityService := services.NewActivityService(activityCollection)
+
+	blobStore, err := services.NewBlobStore()
+	if err != nil {
+		log.Fatalf("Error al configurar el almacenamiento de archivos: %v", err)
+	}
+	attachmentService := services.NewAttachmentService(attachmentUsageCollection, taskService, blobStore)
+	recurrenceService := services.NewRecurrenceService(taskService, boardService)
+	workLogService := services.NewWorkLogService(workLogCollection, timerCollection, taskService, boardService)
+	burndownService := services.NewBurndownService(taskService, activityService)
+	completionService := services.NewCompletionService(boardService, taskService, activityService)
+	templateService := services.NewTemplateService(templateCollection, boardService, taskService)
+	rolloverService := services.NewRolloverService(taskService, boardService, commentService, workLogService, activityService)
+
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := boardService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de boards: %v", err)
+	}
+	if err := taskService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de tasks: %v", err)
+	}
+	if err := notificationService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de notifications: %v", err)
+	}
+	if err := commentService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de comments: %v", err)
+	}
+	if err := activityService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de activity: %v", err)
+	}
+	if err := workLogService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de work logs: %v", err)
+	}
+	if err := templateService.EnsureIndexes(indexCtx); err != nil {
+		log.Fatalf("Error al crear índices de templates: %v", err)
+	}
+	if err := boardService.EnsureWorkflows(indexCtx); err != nil {
+		log.Fatalf("Error al migrar los workflows de boards: %v", err)
+	}
+	if err := taskService.EnsureStatusCategories(indexCtx); err != nil {
+		log.Fatalf("Error al migrar las categorías de tasks: %v", err)
+	}
+	if err := taskService.EnsureRanks(indexCtx); err != nil {
+		log.Fatalf("Error al asignar el orden de las tasks: %v", err)
+	}
+	cancelIndexes()
+
+	schedulerCtx, stopSchedulers := context.WithCancel(context.Background())
+	defer stopSchedulers()
+
+	reminderScheduler := services.NewReminderScheduler(taskService, boardService, notificationService, time.Minute)
+	go reminderScheduler.Run(schedulerCtx)
+
+	recurrenceScheduler := services.NewRecurrenceScheduler(recurrenceService, time.Minute)
+	go recurrenceScheduler.Run(schedulerCtx)
 
-	boardController := handlers.NewBoardHandler(boardService, taskService)
-	taskController := handlers.NewTaskHandler(taskService, boardService)
+	rolloverScheduler := services.NewRolloverScheduler(rolloverService, time.Minute)
+	go rolloverScheduler.Run(schedulerCtx)
+
+	boardController := handlers.NewBoardHandler(boardService, taskService, commentService, attachmentService, activityService, workLogService, burndownService, rolloverService, completionService, userService)
+	taskController := handlers.NewTaskHandler(taskService, boardService, notificationService, commentService, attachmentService, recurrenceService, activityService, workLogService, completionService)
 	authController := handlers.NewAuthHandler(authService, userService)
-	userController := handlers.NewUserHandler(userService, boardService, taskService)
+	userController := handlers.NewUserHandler(userService, boardService, taskService, commentService, attachmentService, workLogService, templateService)
+	searchController := handlers.NewSearchHandler(searchService)
+	notificationController := handlers.NewNotificationHandler(notificationService)
+	commentController := handlers.NewCommentHandler(commentService, taskService, boardService, notificationService)
+	attachmentController := handlers.NewAttachmentHandler(attachmentService, taskService, boardService)
+	workLogController := handlers.NewWorkLogHandler(workLogService, taskService, boardService)
+	templateController := handlers.NewTemplateHandler(templateService, boardService, activityService)
 
 	authMiddleware := middlewares.NewAuthMiddleware(authService)
+	archiveMiddleware := middlewares.NewArchiveMiddleware(boardService, taskService)
 
 	router := mux.NewRouter()
 	apiRouter := router.PathPrefix("/api/v1").Subrouter()
 
 	taskRouter := apiRouter.PathPrefix("/tasks").Subrouter()
-	routes.TaskRouter(taskRouter, taskController, authMiddleware)
+	routes.TaskRouter(taskRouter, taskController, authMiddleware, archiveMiddleware)
+	routes.CommentRouter(taskRouter, commentController, authMiddleware, archiveMiddleware)
+	routes.AttachmentRouter(taskRouter, attachmentController, authMiddleware, archiveMiddleware)
+	routes.WorkLogRouter(taskRouter, workLogController, authMiddleware, archiveMiddleware)
 
 	boardRouter := apiRouter.PathPrefix("/boards").Subrouter()
-	routes.BoardRouter(boardRouter, boardController, authMiddleware)
+	routes.BoardRouter(boardRouter, boardController, authMiddleware, archiveMiddleware)
+	routes.BoardTemplateRouter(boardRouter, templateController, authMiddleware)
+
+	templateRouter := apiRouter.PathPrefix("/templates").Subrouter()
+	routes.TemplateRouter(templateRouter, templateController, authMiddleware)
 
 	userRouter := apiRouter.PathPrefix("/users").Subrouter()
 	routes.UserRouter(userRouter, userController, authMiddleware)
+	routes.TimesheetRouter(userRouter, workLogController, authMiddleware)
 
 	authRouter := apiRouter.PathPrefix("/auth").Subrouter()
 	routes.AuthRouter(authRouter, authController, authMiddleware)
 
+	searchRouter := apiRouter.PathPrefix("/search").Subrouter()
+	routes.SearchRouter(searchRouter, searchController, authMiddleware)
+
+	notificationRouter := apiRouter.PathPrefix("/notifications").Subrouter()
+	routes.NotificationRouter(notificationRouter, notificationController, authMiddleware)
+
 	router.HandleFunc("/", handlers.Root).Methods("GET")
 
 	err = router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -93,9 +187,9 @@ func main() {
 	corsOptions := gorillaHandlers.CORS(
 		gorillaHandlers.AllowedOrigins([]string{"http://localhost:5173"}),
 		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization", "If-Match"}),
 		gorillaHandlers.AllowCredentials(),
-		gorillaHandlers.ExposedHeaders([]string{"Set-Cookie"}),
+		gorillaHandlers.ExposedHeaders([]string{"Set-Cookie", "ETag"}),
 	)
 
 	if err := http.ListenAndServe(port, corsOptions(router)); err != nil {
@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"net/http"
+	"todoerbk/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ArchiveMiddleware keeps archived boards and their tasks read-only
+type ArchiveMiddleware struct {
+	BoardService *services.BoardService
+	TaskService  *services.TaskService
+}
+
+func NewArchiveMiddleware(boardService *services.BoardService, taskService *services.TaskService) *ArchiveMiddleware {
+	return &ArchiveMiddleware{
+		BoardService: boardService,
+		TaskService:  taskService,
+	}
+}
+
+// RejectArchivedBoard answers 409 when the board of the route is archived. A board that cannot be
+// found is left to the handler, which answers its own not found.
+func (m *ArchiveMiddleware) RejectArchivedBoard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		board, err := m.BoardService.GetBoardById(r.Context(), mux.Vars(r)["id"])
+		if err == nil && board.Archived {
+			http.Error(w, services.ErrBoardArchived.Error(), http.StatusConflict)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RejectArchivedTask answers 409 when the task of the route is on an archived board
+func (m *ArchiveMiddleware) RejectArchivedTask(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task, err := m.TaskService.GetTaskById(r.Context(), mux.Vars(r)["id"])
+		if err == nil {
+			board, err := m.BoardService.GetBoardById(r.Context(), task.BoardID.Hex())
+			if err == nil && board.Archived {
+				http.Error(w, services.ErrBoardArchived.Error(), http.StatusConflict)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 	"todoerbk/models"
+	"todoerbk/services"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -24,6 +28,12 @@ const LoginRequestKey contextKey = "login_request"
 const LogoutRequestKey contextKey = "logout_request"
 const ForgetRequestKey authKey = "forget_request"
 const ResetPasswordRequestKey authKey = "reset_password_request"
+const PageRequestKey contextKey = "page_request"
+const TaskQueryKey contextKey = "task_query"
+const TimesheetQueryKey contextKey = "timesheet_query"
+const BurndownQueryKey contextKey = "burndown_query"
+const BoardQueryKey contextKey = "board_query"
+const ExportQueryKey contextKey = "export_query"
 
 func getAllValidationErrs(err error) []map[string]string {
 	var validationErrors validator.ValidationErrors
@@ -95,22 +105,23 @@ func ValidateTask(next http.Handler) http.Handler {
 			return
 		}
 
-		if task.Status != "" && !task.Status.IsValid() {
+		// The status depends on the board workflow, the handler checks it once the board is loaded
+		if task.Priority != "" && !task.Priority.IsValid() {
 			w.WriteHeader(http.StatusBadRequest)
 			response := map[string]interface{}{
 				"success": false,
 				"message": "Error in task model validation",
-				"errors":  []string{"Invalid Task Status. < field: status, value: TODO, DOING, DONE >"},
+				"errors":  []string{"Invalid Task Priority. < field: priority, value: LOW, MEDIUM, HIGH >"},
 			}
 			json.NewEncoder(w).Encode(response)
 			return
 		}
-		if task.Priority != "" && !task.Priority.IsValid() {
+		if len(task.Reminders) > 0 && task.DueDate == nil {
 			w.WriteHeader(http.StatusBadRequest)
 			response := map[string]interface{}{
 				"success": false,
 				"message": "Error in task model validation",
-				"errors":  []string{"Invalid Task Priority. < field: priority, value: LOW, MEDIUM, HIGH >"},
+				"errors":  []string{"Reminders require a due date. < field: due_date >"},
 			}
 			json.NewEncoder(w).Encode(response)
 			return
@@ -436,3 +447,296 @@ func ValidateResetPasswordRequest(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+func DecodePageRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		page := models.PageRequest{
+			Limit:  services.DefaultPageSize,
+			Cursor: query.Get("cursor"),
+		}
+
+		var errs []string
+		if rawLimit := query.Get("limit"); rawLimit != "" {
+			limit, err := strconv.ParseInt(rawLimit, 10, 64)
+			if err != nil || limit < 1 {
+				errs = append(errs, "Invalid limit. < field: limit, value: integer >= 1 >")
+			} else if limit > services.MaxPageSize {
+				limit = services.MaxPageSize
+			}
+			page.Limit = limit
+		}
+		if page.Cursor != "" && services.ValidateCursor(page.Cursor) != nil {
+			errs = append(errs, "Invalid cursor. Use the next_cursor returned by the previous page")
+		}
+
+		if len(errs) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			response := map[string]interface{}{
+				"success": false,
+				"message": "Error in pagination params",
+				"errors":  errs,
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), PageRequestKey, page)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// taskQueryParams are the query params accepted by task listings, anything else is rejected
+var taskQueryParams = map[string]bool{
+	"status": true, "priority": true, "title": true, "board_id": true,
+	"created_from": true, "created_to": true, "updated_from": true, "updated_to": true,
+	"overdue": true, "labels": true, "label_match": true, "sort": true, "order": true, "limit": true, "cursor": true,
+}
+
+// splitQueryValues accepts both ?status=TODO&status=DOING and ?status=TODO,DOING
+func splitQueryValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+func DecodeTaskQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		var query models.TaskQuery
+		var errs []string
+
+		for key := range params {
+			if !taskQueryParams[key] {
+				errs = append(errs, "Unknown query param '"+key+"'")
+			}
+		}
+
+		// Statuses come from the board workflows, so any name is a valid filter
+		for _, value := range splitQueryValues(params["status"]) {
+			if status := services.NormalizeStatus(models.TaskStatus(value)); status != "" {
+				query.Statuses = append(query.Statuses, status)
+			}
+		}
+		for _, value := range splitQueryValues(params["priority"]) {
+			priority := models.TaskPriority(strings.ToUpper(value))
+			if !priority.IsValid() {
+				errs = append(errs, "Invalid Task Priority. < field: priority, value: LOW, MEDIUM, HIGH >")
+				continue
+			}
+			query.Priorities = append(query.Priorities, priority)
+		}
+		query.TitleContains = strings.TrimSpace(params.Get("title"))
+
+		if boardID := params.Get("board_id"); boardID != "" {
+			objID, err := primitive.ObjectIDFromHex(boardID)
+			if err != nil {
+				errs = append(errs, "Invalid Board ID. < field: board_id >")
+			} else {
+				query.BoardID = &objID
+			}
+		}
+
+		dates := map[string]**time.Time{
+			"created_from": &query.CreatedFrom,
+			"created_to":   &query.CreatedTo,
+			"updated_from": &query.UpdatedFrom,
+			"updated_to":   &query.UpdatedTo,
+		}
+		for key, target := range dates {
+			value := params.Get(key)
+			if value == "" {
+				continue
+			}
+			date, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				errs = append(errs, "Invalid date. < field: "+key+", value: RFC3339 date >")
+				continue
+			}
+			*target = &date
+		}
+
+		for _, value := range splitQueryValues(params["labels"]) {
+			labelID, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				errs = append(errs, "Invalid Label ID. < field: labels >")
+				continue
+			}
+			query.LabelIDs = append(query.LabelIDs, labelID)
+		}
+		switch strings.ToLower(params.Get("label_match")) {
+		case "", "any":
+		case "all":
+			query.MatchAllLabels = true
+		default:
+			errs = append(errs, "Invalid label_match. < field: label_match, value: any, all >")
+		}
+
+		switch strings.ToLower(params.Get("overdue")) {
+		case "", "false":
+		case "true":
+			query.Overdue = true
+		default:
+			errs = append(errs, "Invalid overdue. < field: overdue, value: true, false >")
+		}
+
+		if sortBy := params.Get("sort"); sortBy != "" {
+			query.SortBy = models.TaskSortField(strings.ToLower(sortBy))
+			if !query.SortBy.IsValid() {
+				errs = append(errs, "Invalid sort. < field: sort, value: priority, created_at, updated_at, title, position >")
+			}
+		}
+		switch strings.ToLower(params.Get("order")) {
+		case "", "asc":
+		case "desc":
+			query.SortDesc = true
+		default:
+			errs = append(errs, "Invalid order. < field: order, value: asc, desc >")
+		}
+
+		if len(errs) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			response := map[string]interface{}{
+				"success": false,
+				"message": "Error in task query params",
+				"errors":  errs,
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), TaskQueryKey, query)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// maxTimesheetDays bounds the range of a timesheet
+const maxTimesheetDays = 366
+
+// DecodeTimesheetQuery reads ?from=2024-01-01&to=2024-01-31, both days included, and ?format=json|csv
+func DecodeTimesheetQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		var query models.TimesheetQuery
+		var errs []string
+
+		days := map[string]*time.Time{"from": &query.From, "to": &query.To}
+		for _, key := range []string{"from", "to"} {
+			date, err := time.Parse("2006-01-02", params.Get(key))
+			if err != nil {
+				errs = append(errs, "Invalid date. < field: "+key+", value: YYYY-MM-DD date >")
+				continue
+			}
+			*days[key] = date
+		}
+		if len(errs) == 0 && (query.To.Before(query.From) || query.To.Sub(query.From) >= maxTimesheetDays*24*time.Hour) {
+			errs = append(errs, "Invalid range. < field: to, value: from the from date up to 366 days >")
+		}
+
+		switch strings.ToLower(params.Get("format")) {
+		case "", "json":
+		case "csv":
+			query.CSV = true
+		default:
+			errs = append(errs, "Invalid format. < field: format, value: json, csv >")
+		}
+
+		if len(errs) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			response := map[string]interface{}{
+				"success": false,
+				"message": "Error in timesheet query params",
+				"errors":  errs,
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), TimesheetQueryKey, query)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// DecodeBurndownQuery reads the unit of a burndown, task count unless ?unit=points
+func DecodeBurndownQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := models.BurndownQuery{Unit: models.BurndownCount}
+		switch unit := models.BurndownUnit(strings.ToLower(r.URL.Query().Get("unit"))); unit {
+		case "":
+		case models.BurndownCount, models.BurndownPoints:
+			query.Unit = unit
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			response := map[string]interface{}{
+				"success": false,
+				"message": "Error in burndown query params",
+				"errors":  []string{"Invalid unit. < field: unit, value: count, points >"},
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), BurndownQueryKey, query)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// DecodeBoardQuery reads which boards a listing returns, the ones not archived unless ?archived=true|all
+func DecodeBoardQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := models.BoardQuery{Archived: models.BoardsActive}
+		switch archived := models.BoardArchiveFilter(strings.ToLower(r.URL.Query().Get("archived"))); archived {
+		case "":
+		case models.BoardsActive, models.BoardsArchived, models.BoardsAll:
+			query.Archived = archived
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			response := map[string]interface{}{
+				"success": false,
+				"message": "Error in board query params",
+				"errors":  []string{"Invalid archived. < field: archived, value: false, true, all >"},
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), BoardQueryKey, query)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// DecodeExportQuery reads the format of a board export, ?format=json|csv
+func DecodeExportQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query models.ExportQuery
+		switch strings.ToLower(r.URL.Query().Get("format")) {
+		case "", "json":
+		case "csv":
+			query.CSV = true
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			response := map[string]interface{}{
+				"success": false,
+				"message": "Error in export query params",
+				"errors":  []string{"Invalid format. < field: format, value: json, csv >"},
+			}
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ExportQueryKey, query)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const IfMatchKey contextKey = "if_match"
+
+// ETag formats a document version as a strong entity tag
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// RequireIfMatch rejects requests without an If-Match header and stores the versions it lists in the context.
+// The header is read as in RFC 9110: "*" or a comma separated list of entity tags. If-Match compares
+// strongly, so weak W/ tags, like tags that are not versions of ours, are kept as matching nothing.
+// "If-Match: *" leaves the versions unset, so the handler uses the stored one.
+func RequireIfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := strings.TrimSpace(r.Header.Get("If-Match"))
+		if header == "" {
+			http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+			return
+		}
+		if header == "*" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		versions, ok := parseEntityTags(header)
+		if !ok {
+			http.Error(w, "Invalid If-Match header", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), IfMatchKey, versions)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// parseEntityTags returns the versions of the strong tags of a list of entity tags,
+// it fails when an element is not an entity tag
+func parseEntityTags(header string) ([]int64, bool) {
+	versions := []int64{}
+	tags := 0
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		weak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || strings.Contains(tag[1:len(tag)-1], `"`) {
+			return nil, false
+		}
+		tags++
+		if weak {
+			continue
+		}
+		if version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil && version >= 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, tags > 0
+}
+
+// IfMatchVersion returns the version a write must expect and true when the request has an If-Match list:
+// current when the list has it, otherwise a version no document is at, so the write fails as a mismatch.
+// Without a list it returns current and false.
+func IfMatchVersion(r *http.Request, current int64) (int64, bool) {
+	versions, ok := r.Context().Value(IfMatchKey).([]int64)
+	if !ok {
+		return current, false
+	}
+	for _, version := range versions {
+		if version == current {
+			return current, true
+		}
+	}
+	return -1, true
+}
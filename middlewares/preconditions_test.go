@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEntityTags(t *testing.T) {
+	tests := []struct {
+		header   string
+		versions []int64
+		ok       bool
+	}{
+		{`"3"`, []int64{3}, true},
+		{`"3", "4"`, []int64{3, 4}, true},
+		{`"3",,"4"`, []int64{3, 4}, true},
+		{`W/"3"`, []int64{}, true},
+		{`W/"3", "4"`, []int64{4}, true},
+		{`"abc"`, []int64{}, true},
+		{`"-1"`, []int64{}, true},
+		{`3`, nil, false},
+		{`"3`, nil, false},
+		{`W/3`, nil, false},
+		{`"3"4"`, nil, false},
+		{`,`, nil, false},
+	}
+	for _, tt := range tests {
+		versions, ok := parseEntityTags(tt.header)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(versions, tt.versions)) {
+			t.Errorf("parseEntityTags(%q) = %v, %v, want %v, %v", tt.header, versions, ok, tt.versions, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"todoerbk/models"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const ChecklistItemRequestKey contextKey = "checklist_item_request"
+const ChecklistItemUpdateRequestKey contextKey = "checklist_item_update_request"
+const ChecklistOrderRequestKey contextKey = "checklist_order_request"
+const DependencyRequestKey contextKey = "dependency_request"
+const LabelRequestKey contextKey = "label_request"
+const CommentRequestKey contextKey = "comment_request"
+const MoveTaskRequestKey contextKey = "move_task_request"
+const WorkflowRequestKey contextKey = "workflow_request"
+const RecurrenceRequestKey contextKey = "recurrence_request"
+const TaskTransferRequestKey contextKey = "task_transfer_request"
+const BulkTaskTransferRequestKey contextKey = "bulk_task_transfer_request"
+const BulkTaskRequestKey contextKey = "bulk_task_request"
+const WorkLogRequestKey contextKey = "work_log_request"
+const RolloverRequestKey contextKey = "rollover_request"
+const BoardTemplateRequestKey contextKey = "board_template_request"
+const BoardFromTemplateRequestKey contextKey = "board_from_template_request"
+const BoardCloneRequestKey contextKey = "board_clone_request"
+const BoardMemberRequestKey contextKey = "board_member_request"
+
+var DecodeChecklistItemRequest = decodeRequest[models.ChecklistItemRequest](ChecklistItemRequestKey, "checklist item")
+var DecodeChecklistItemUpdateRequest = decodeRequest[models.ChecklistItemUpdateRequest](ChecklistItemUpdateRequestKey, "checklist item")
+var DecodeChecklistOrderRequest = decodeRequest[models.ChecklistOrderRequest](ChecklistOrderRequestKey, "checklist order")
+var DecodeDependencyRequest = decodeRequest[models.DependencyRequest](DependencyRequestKey, "dependency")
+var DecodeLabelRequest = decodeRequest[models.LabelRequest](LabelRequestKey, "label")
+var DecodeCommentRequest = decodeRequest[models.CommentRequest](CommentRequestKey, "comment")
+var DecodeMoveTaskRequest = decodeRequest[models.MoveTaskRequest](MoveTaskRequestKey, "move")
+var DecodeWorkflowRequest = decodeRequest[models.WorkflowRequest](WorkflowRequestKey, "workflow")
+var DecodeRecurrenceRequest = decodeRequest[models.RecurrenceRequest](RecurrenceRequestKey, "recurrence")
+var DecodeTaskTransferRequest = decodeRequest[models.TaskTransferRequest](TaskTransferRequestKey, "transfer")
+var DecodeBulkTaskTransferRequest = decodeRequest[models.BulkTaskTransferRequest](BulkTaskTransferRequestKey, "transfer")
+var DecodeBulkTaskRequest = decodeRequest[models.BulkTaskRequest](BulkTaskRequestKey, "bulk operation")
+var DecodeWorkLogRequest = decodeRequest[models.WorkLogRequest](WorkLogRequestKey, "work log")
+var DecodeRolloverRequest = decodeRequest[models.RolloverRequest](RolloverRequestKey, "rollover")
+var DecodeBoardTemplateRequest = decodeRequest[models.BoardTemplateRequest](BoardTemplateRequestKey, "template")
+var DecodeBoardFromTemplateRequest = decodeRequest[models.BoardFromTemplateRequest](BoardFromTemplateRequestKey, "board")
+var DecodeBoardCloneRequest = decodeRequest[models.BoardCloneRequest](BoardCloneRequestKey, "clone")
+var DecodeBoardMemberRequest = decodeRequest[models.BoardMemberRequest](BoardMemberRequestKey, "member")
+
+// decodeRequest builds a middleware that decodes the JSON body into T, validates it and stores it in the context under key
+func decodeRequest[T any](key contextKey, model string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request T
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				response := map[string]interface{}{
+					"success": false,
+					"message": "Error in " + model + " validation",
+					"errors":  []string{"Invalid JSON. Verify the data sent"},
+				}
+				json.NewEncoder(w).Encode(response)
+				return
+			}
+
+			if err := validate.Struct(request); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				response := map[string]interface{}{
+					"success": false,
+					"message": "Error in " + model + " validation",
+					"errors":  getAllValidationErrs(err),
+				}
+				json.NewEncoder(w).Encode(response)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), key, request)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ValidateModelIdsFromParams checks every id in the route, e.g. /tasks/{id}/checklist/{itemId}
+func ValidateModelIdsFromParams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range mux.Vars(r) {
+			if name != "id" && !strings.HasSuffix(name, "Id") {
+				continue
+			}
+			if _, err := primitive.ObjectIDFromHex(value); err != nil {
+				http.Error(w, "Invalid Model ID: "+name, http.StatusBadRequest)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
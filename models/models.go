@@ -6,16 +6,46 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// TaskStatus is the name of a column of the board workflow
 type TaskStatus string
 
+// Statuses of the default workflow
 const (
 	TODO  TaskStatus = "TODO"
 	DOING TaskStatus = "DOING"
 	DONE  TaskStatus = "DONE"
 )
 
-func (s TaskStatus) IsValid() bool {
-	return s == TODO || s == DOING || s == DONE
+// StatusCategory tells what a workflow column means for completion, whatever its name
+type StatusCategory string
+
+const (
+	CategoryTodo       StatusCategory = "todo"
+	CategoryInProgress StatusCategory = "in_progress"
+	CategoryDone       StatusCategory = "done"
+)
+
+func (c StatusCategory) IsValid() bool {
+	return c == CategoryTodo || c == CategoryInProgress || c == CategoryDone
+}
+
+// WorkflowColumn -- Ordered status column of a board
+type WorkflowColumn struct {
+	Status   TaskStatus     `json:"status" bson:"status" validate:"required,max=40"`
+	Category StatusCategory `json:"category" bson:"category" validate:"required"`
+	// Transitions lists the statuses a task can go to from this column, empty allows any
+	Transitions []TaskStatus `json:"transitions,omitempty" bson:"transitions,omitempty"`
+	// WIPLimit is the maximum number of tasks in the column, 0 means no limit
+	WIPLimit int `json:"wip_limit,omitempty" bson:"wip_limit,omitempty" validate:"min=0"`
+}
+
+// DefaultWorkflow is the TODO, DOING, DONE workflow boards had before workflows were configurable
+func DefaultWorkflow() []WorkflowColumn {
+	return []WorkflowColumn{
+		{Status: TODO, Category: CategoryTodo},
+		{Status: DOING, Category: CategoryInProgress},
+		{Status: DONE, Category: CategoryDone},
+	}
 }
 
 type TaskPriority string
@@ -30,26 +60,235 @@ func (p TaskPriority) IsValid() bool {
 	return p == LOW || p == MEDIUM || p == HIGH
 }
 
+// Rank gives the semantic order of priorities: HIGH first, then MEDIUM, then LOW
+func (p TaskPriority) Rank() int {
+	switch p {
+	case HIGH:
+		return 0
+	case MEDIUM:
+		return 1
+	case LOW:
+		return 2
+	default:
+		return 3
+	}
+}
+
 type Task struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
-	Title     string             `json:"title" bson:"title" validate:"min=4"`
-	Status    TaskStatus         `json:"status" bson:"status"`
-	Priority  TaskPriority       `json:"priority" bson:"priority"`
-	BoardID   primitive.ObjectID `json:"board_id" bson:"board_id" validate:"required"`
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+	Title     string               `json:"title" bson:"title" validate:"min=4"`
+	Status    TaskStatus           `json:"status" bson:"status"`
+	Priority  TaskPriority         `json:"priority" bson:"priority"`
+	BoardID   primitive.ObjectID   `json:"board_id" bson:"board_id" validate:"required"`
+	Version   int64                `json:"version" bson:"version"`
+	DueDate   *time.Time           `json:"due_date,omitempty" bson:"due_date,omitempty"`
+	Reminders []Reminder           `json:"reminders,omitempty" bson:"reminders,omitempty" validate:"dive"`
+	Assignees []primitive.ObjectID `json:"assignees,omitempty" bson:"assignees,omitempty"`
+	Checklist []ChecklistItem      `json:"checklist,omitempty" bson:"checklist,omitempty" validate:"dive"`
+	BlockedBy []primitive.ObjectID `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	LabelIDs  []primitive.ObjectID `json:"label_ids,omitempty" bson:"label_ids,omitempty"`
+	// StatusCategory is the category of the status column, kept in sync with the board workflow
+	StatusCategory StatusCategory `json:"status_category" bson:"status_category"`
+	// Rank orders the task inside its status column, compared as a string
+	Rank       string      `json:"rank" bson:"rank"`
+	Recurrence *Recurrence `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	// Attachments are only changed through the attachment endpoints
+	Attachments []Attachment `json:"attachments,omitempty" bson:"attachments,omitempty"`
+	// Estimates are in minutes, the remaining one goes down as work is logged
+	OriginalEstimate  int `json:"original_estimate_minutes,omitempty" bson:"original_estimate_minutes,omitempty" validate:"min=0"`
+	RemainingEstimate int `json:"remaining_estimate_minutes,omitempty" bson:"remaining_estimate_minutes,omitempty" validate:"min=0"`
+	// StoryPoints size the task for the burndown of the board
+	StoryPoints int `json:"story_points,omitempty" bson:"story_points,omitempty" validate:"min=0"`
+	// LoggedMinutes is the sum of the work logs of the task, only changed by them
+	LoggedMinutes int `json:"logged_minutes" bson:"logged_minutes"`
+	// CopiedFrom is the task this one was copied from, possibly on another board
+	CopiedFrom *primitive.ObjectID `json:"copied_from,omitempty" bson:"copied_from,omitempty"`
+	// RolledOverFrom is the finished board the task was rolled over from
+	RolledOverFrom *primitive.ObjectID `json:"rolled_over_from,omitempty" bson:"rolled_over_from,omitempty"`
+	// ChecklistProgress is derived from Checklist every time the task is saved
+	ChecklistProgress ChecklistProgress `json:"checklist_progress" bson:"checklist_progress"`
+}
+
+// ChecklistItem -- Ordered step inside a task
+type ChecklistItem struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id"`
+	Text       string              `json:"text" bson:"text" validate:"required"`
+	Done       bool                `json:"done" bson:"done"`
+	AssigneeID *primitive.ObjectID `json:"assignee_id,omitempty" bson:"assignee_id,omitempty"`
+}
+
+type ChecklistProgress struct {
+	Total int `json:"total" bson:"total"`
+	Done  int `json:"done" bson:"done"`
+}
+
+// Reminder -- Notification sent OffsetMinutes before the task due date
+type Reminder struct {
+	OffsetMinutes int        `json:"offset_minutes" bson:"offset_minutes" validate:"min=0"`
+	RemindAt      time.Time  `json:"remind_at" bson:"remind_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
 }
 
 // Board Model -- Set of tasks for a specific time period
 type Board struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+	Title     string               `json:"title" bson:"title" validate:"min=4"`
+	FromDate  time.Time            `json:"from_date" bson:"from_date" validate:"required" ` //validar que sea una fecha valida
+	ToDate    time.Time            `json:"to_date" bson:"to_date" validate:"required"`      //validar que sea una fecha valida y posterior a la fecha de inicio
+	Completed bool                 `json:"completed" bson:"completed" default:"false"`
+	OwnerID   primitive.ObjectID   `json:"owner_id" bson:"owner_id"`
+	Version   int64                `json:"version" bson:"version"`
+	Members   []primitive.ObjectID `json:"members,omitempty" bson:"members,omitempty"`
+	Labels    []Label              `json:"labels,omitempty" bson:"labels,omitempty" validate:"dive"`
+	Workflow  []WorkflowColumn     `json:"workflow" bson:"workflow" validate:"max=20,dive"`
+	// AutoRollover rolls the unfinished tasks over once the board ends, empty to roll them over by hand
+	AutoRollover RolloverMode `json:"auto_rollover,omitempty" bson:"auto_rollover,omitempty" validate:"omitempty,oneof=move copy"`
+	// The boards before and after this one in a chain of rollovers
+	RolledOverFrom *primitive.ObjectID `json:"rolled_over_from,omitempty" bson:"rolled_over_from,omitempty"`
+	RolledOverTo   *primitive.ObjectID `json:"rolled_over_to,omitempty" bson:"rolled_over_to,omitempty"`
+	// NextRolloverAt holds the scheduler back from a board whose automatic rollover failed
+	NextRolloverAt *time.Time `json:"-" bson:"next_rollover_at,omitempty"`
+	// CompletionRules tie Completed to the tasks, CompletionReason tells why Completed last changed
+	CompletionRules  CompletionRules  `json:"completion_rules" bson:"completion_rules"`
+	CompletionReason CompletionReason `json:"completion_reason,omitempty" bson:"completion_reason,omitempty"`
+	// Archived boards are read-only and left out of the default listings, whether completed or not
+	Archived   bool       `json:"archived" bson:"archived"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+}
+
+// CompletionRules -- How the tasks of a board complete and reopen it
+type CompletionRules struct {
+	// AutoComplete completes the board when its last open task is done
+	AutoComplete bool `json:"auto_complete" bson:"auto_complete"`
+	// AutoReopen reopens a completed board when a task is added to it or reopened
+	AutoReopen bool `json:"auto_reopen" bson:"auto_reopen"`
+	// RequireTasksDone refuses to complete by hand a board with open tasks, unless forced
+	RequireTasksDone bool `json:"require_tasks_done" bson:"require_tasks_done"`
+}
+
+type CompletionReason string
+
+const (
+	CompletedManually    CompletionReason = "completed_manually"
+	CompletedForced      CompletionReason = "completed_with_open_tasks"
+	CompletedAllDone     CompletionReason = "all_tasks_done"
+	CompletedRolledOver  CompletionReason = "rolled_over"
+	ReopenedManually     CompletionReason = "reopened_manually"
+	ReopenedTaskAdded    CompletionReason = "task_added"
+	ReopenedTaskReopened CompletionReason = "task_reopened"
+)
+
+// BoardTemplate -- Skeleton of a board saved by its owner to create new boards from
+type BoardTemplate struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
-	Title     string             `json:"title" bson:"title" validate:"min=4"`
-	FromDate  time.Time          `json:"from_date" bson:"from_date" validate:"required" ` //validar que sea una fecha valida
-	ToDate    time.Time          `json:"to_date" bson:"to_date" validate:"required"`      //validar que sea una fecha valida y posterior a la fecha de inicio
-	Completed bool               `json:"completed" bson:"completed" default:"false"`
 	OwnerID   primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	Name      string             `json:"name" bson:"name"`
+	// TitlePattern is the title of the new boards, {from}, {to} and {week} are replaced with their dates
+	TitlePattern    string           `json:"title_pattern" bson:"title_pattern"`
+	DurationDays    int              `json:"duration_days" bson:"duration_days"`
+	Workflow        []WorkflowColumn `json:"workflow" bson:"workflow"`
+	Labels          []Label          `json:"labels,omitempty" bson:"labels,omitempty"`
+	Tasks           []TemplateTask   `json:"tasks,omitempty" bson:"tasks,omitempty"`
+	CompletionRules CompletionRules  `json:"completion_rules" bson:"completion_rules"`
+}
+
+// TemplateTask -- Starter task of a board template, created in the first column of the new boards.
+// Labels are template labels and the due date is in days from the start of the board.
+type TemplateTask struct {
+	Title            string               `json:"title" bson:"title"`
+	Priority         TaskPriority         `json:"priority" bson:"priority"`
+	LabelIDs         []primitive.ObjectID `json:"label_ids,omitempty" bson:"label_ids,omitempty"`
+	Checklist        []string             `json:"checklist,omitempty" bson:"checklist,omitempty"`
+	StoryPoints      int                  `json:"story_points,omitempty" bson:"story_points,omitempty"`
+	OriginalEstimate int                  `json:"original_estimate_minutes,omitempty" bson:"original_estimate_minutes,omitempty"`
+	DueInDays        *int                 `json:"due_in_days,omitempty" bson:"due_in_days,omitempty"`
+}
+
+// RolloverMode tells whether the unfinished tasks of a board are moved or copied to the next one
+type RolloverMode string
+
+const (
+	RolloverMove RolloverMode = "move"
+	RolloverCopy RolloverMode = "copy"
+)
+
+// Label -- Named and colored tag from the board catalog, tasks reference it by id
+type Label struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id"`
+	Name  string             `json:"name" bson:"name" validate:"required"`
+	Color string             `json:"color" bson:"color" validate:"required,hexcolor"`
+}
+
+// Label returns the catalog label with the id
+func (b Board) Label(labelID primitive.ObjectID) (*Label, bool) {
+	for i := range b.Labels {
+		if b.Labels[i].ID == labelID {
+			return &b.Labels[i], true
+		}
+	}
+	return nil, false
+}
+
+// Column returns the workflow column of the status
+func (b Board) Column(status TaskStatus) (*WorkflowColumn, bool) {
+	for i := range b.Workflow {
+		if b.Workflow[i].Status == status {
+			return &b.Workflow[i], true
+		}
+	}
+	return nil, false
+}
+
+// Statuses returns the statuses of the workflow in column order
+func (b Board) Statuses() []TaskStatus {
+	statuses := make([]TaskStatus, 0, len(b.Workflow))
+	for _, column := range b.Workflow {
+		statuses = append(statuses, column.Status)
+	}
+	return statuses
+}
+
+// InitialStatus is the status of new tasks: the first column of the workflow
+func (b Board) InitialStatus() TaskStatus {
+	if len(b.Workflow) == 0 {
+		return TODO
+	}
+	return b.Workflow[0].Status
+}
+
+// CanTransition tells if the workflow lets a task go from one status to another
+func (b Board) CanTransition(from, to TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	column, ok := b.Column(from)
+	if !ok || len(column.Transitions) == 0 {
+		return true
+	}
+	for _, status := range column.Transitions {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMember tells if the user owns the board or was added to it
+func (b Board) IsMember(userID primitive.ObjectID) bool {
+	if b.OwnerID == userID {
+		return true
+	}
+	for _, member := range b.Members {
+		if member == userID {
+			return true
+		}
+	}
+	return false
 }
 
 type User struct {
@@ -63,3 +302,122 @@ type User struct {
 	ResetCodeExp time.Time          `json:"-" bson:"reset_code_exp,omitempty"`
 	IsActive     bool               `json:"is_active" bson:"is_active" default:"true"`
 }
+
+type NotificationType string
+
+const (
+	TaskReminderNotification NotificationType = "TASK_REMINDER"
+	TaskAssignedNotification NotificationType = "TASK_ASSIGNED"
+	TaskMentionNotification  NotificationType = "TASK_MENTION"
+)
+
+// Notification Model -- In-app notification, also sent by email when SMTP is configured
+type Notification struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+	UserID    primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Type      NotificationType    `json:"type" bson:"type"`
+	Message   string              `json:"message" bson:"message"`
+	TaskID    *primitive.ObjectID `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	BoardID   *primitive.ObjectID `json:"board_id,omitempty" bson:"board_id,omitempty"`
+	Read      bool                `json:"read" bson:"read"`
+}
+
+// Comment Model -- Markdown message in the discussion of a task
+type Comment struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+	TaskID    primitive.ObjectID   `json:"task_id" bson:"task_id"`
+	BoardID   primitive.ObjectID   `json:"board_id" bson:"board_id"`
+	AuthorID  primitive.ObjectID   `json:"author_id" bson:"author_id"`
+	Body      string               `json:"body" bson:"body"`
+	Edited    bool                 `json:"edited" bson:"edited"`
+	Mentions  []primitive.ObjectID `json:"mentions,omitempty" bson:"mentions,omitempty"`
+}
+
+// Attachment -- Metadata of a file uploaded to a task, the content lives in the blob store
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Filename    string             `json:"filename" bson:"filename"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	Checksum    string             `json:"checksum_sha256" bson:"checksum_sha256"`
+	StorageKey  string             `json:"-" bson:"storage_key"`
+	UploaderID  primitive.ObjectID `json:"uploader_id" bson:"uploader_id"`
+	UploadedAt  time.Time          `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+// Recurrence -- RRULE schedule of a repeating task, every occurrence is a task of the same series
+type Recurrence struct {
+	Rule     string             `json:"rule" bson:"rule" validate:"required"`
+	SeriesID primitive.ObjectID `json:"series_id" bson:"series_id"`
+	// Start is the due date of the first occurrence, the rule counts its intervals from it
+	Start      time.Time `json:"start" bson:"start"`
+	Occurrence int       `json:"occurrence" bson:"occurrence"`
+	// NextID is the task of the following occurrence, set once it is generated
+	NextID  *primitive.ObjectID `json:"next_id,omitempty" bson:"next_id,omitempty"`
+	Stopped bool                `json:"stopped" bson:"stopped"`
+	// Ended is set on the last occurrence once COUNT or UNTIL leave no occurrence after it
+	Ended bool `json:"ended,omitempty" bson:"ended,omitempty"`
+	// NextGenerationAt holds the scheduler back from a task whose next occurrence could not be generated
+	NextGenerationAt *time.Time `json:"-" bson:"next_generation_at,omitempty"`
+}
+
+type ActivityAction string
+
+const (
+	ActivityCreated       ActivityAction = "created"
+	ActivityUpdated       ActivityAction = "updated"
+	ActivityStatusChanged ActivityAction = "status_changed"
+	ActivityMoved         ActivityAction = "moved"
+	ActivityDeleted       ActivityAction = "deleted"
+)
+
+// Activity Model -- Entry of the history of a task or a board: who changed what and when
+type Activity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ActorID   primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	Action    ActivityAction     `json:"action" bson:"action"`
+	// TaskID is empty for the entries of the board itself
+	TaskID  *primitive.ObjectID `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	BoardID primitive.ObjectID  `json:"board_id" bson:"board_id"`
+	// BoardIDs are the boards whose feed shows the entry, both of them when a task changes boards
+	BoardIDs []primitive.ObjectID `json:"-" bson:"board_ids"`
+	Changes  []FieldChange        `json:"changes" bson:"changes"`
+}
+
+// FieldChange -- Value of a stored field before and after a change, null when it was not set
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+type WorkLogSource string
+
+const (
+	WorkLogTimer  WorkLogSource = "timer"
+	WorkLogManual WorkLogSource = "manual"
+)
+
+// WorkLog Model -- Time a user spent on a task, from a timer or logged by hand
+type WorkLog struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	TaskID    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	BoardID   primitive.ObjectID `json:"board_id" bson:"board_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	StartedAt time.Time          `json:"started_at" bson:"started_at"`
+	Minutes   int                `json:"minutes" bson:"minutes"`
+	Note      string             `json:"note,omitempty" bson:"note,omitempty"`
+	Source    WorkLogSource      `json:"source" bson:"source"`
+}
+
+// Timer Model -- Running timer of a user, a user has at most one so its id is the user id
+type Timer struct {
+	UserID    primitive.ObjectID `json:"user_id" bson:"_id"`
+	TaskID    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	StartedAt time.Time          `json:"started_at" bson:"started_at"`
+}
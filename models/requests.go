@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 // Login request
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required"`
@@ -28,3 +34,217 @@ type ResetPasswordRequest struct {
 	Code     string `json:"code" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
+
+// Cursor pagination query params: ?limit=&cursor=
+type PageRequest struct {
+	Limit  int64
+	Cursor string
+}
+
+// Task listing filters and sorting, decoded from the query string of GET /tasks and GET /boards/{id}
+type TaskQuery struct {
+	Statuses       []TaskStatus
+	Priorities     []TaskPriority
+	TitleContains  string
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	UpdatedFrom    *time.Time
+	UpdatedTo      *time.Time
+	BoardID        *primitive.ObjectID
+	Overdue        bool
+	LabelIDs       []primitive.ObjectID
+	MatchAllLabels bool
+	SortBy         TaskSortField
+	SortDesc       bool
+}
+
+type TaskSortField string
+
+const (
+	SortByCreatedAt TaskSortField = "created_at"
+	SortByUpdatedAt TaskSortField = "updated_at"
+	SortByPriority  TaskSortField = "priority"
+	SortByTitle     TaskSortField = "title"
+	// SortByPosition orders by status column and then by rank inside the column, the board order
+	SortByPosition TaskSortField = "position"
+)
+
+func (f TaskSortField) IsValid() bool {
+	return f == SortByCreatedAt || f == SortByUpdatedAt || f == SortByPriority || f == SortByTitle || f == SortByPosition
+}
+
+// Checklist item to add to a task
+type ChecklistItemRequest struct {
+	Text       string              `json:"text" validate:"required"`
+	AssigneeID *primitive.ObjectID `json:"assignee_id"`
+}
+
+// Checklist item changes, only the fields sent are applied
+type ChecklistItemUpdateRequest struct {
+	Text       *string             `json:"text" validate:"omitempty,min=1"`
+	Done       *bool               `json:"done"`
+	AssigneeID *primitive.ObjectID `json:"assignee_id"`
+}
+
+// New order of the checklist, it must contain every item id exactly once
+type ChecklistOrderRequest struct {
+	ItemIDs []primitive.ObjectID `json:"item_ids" validate:"required"`
+}
+
+// User to give access to a board
+type BoardMemberRequest struct {
+	UserID primitive.ObjectID `json:"user_id" validate:"required"`
+}
+
+// Dependency to add to a task: either the task that blocks it or the task it blocks
+type DependencyRequest struct {
+	BlockedBy *primitive.ObjectID `json:"blocked_by" validate:"required_without=Blocks,excluded_with=Blocks"`
+	Blocks    *primitive.ObjectID `json:"blocks" validate:"required_without=BlockedBy"`
+}
+
+// Label to add to the board catalog, or its new name and color
+type LabelRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Color string `json:"color" validate:"required,hexcolor"`
+}
+
+// Comment body in Markdown, @username mentions notify the users
+type CommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+// Drag and drop of a task: the target status and the tasks that end up right above and below it.
+// Without neighbors the task goes to the end of the column.
+type MoveTaskRequest struct {
+	Status TaskStatus          `json:"status" validate:"required"`
+	PrevID *primitive.ObjectID `json:"prev_id,omitempty"`
+	NextID *primitive.ObjectID `json:"next_id,omitempty"`
+}
+
+// Replaces the whole workflow of a board, columns in display order
+type WorkflowRequest struct {
+	Columns []WorkflowColumn `json:"columns" validate:"required,min=1,max=20,dive"`
+}
+
+// New RRULE of a task series, e.g. FREQ=WEEKLY;BYDAY=MO
+type RecurrenceRequest struct {
+	Rule string `json:"rule" validate:"required"`
+}
+
+// Board a task is moved or copied to. Without status the task keeps its status when the target
+// workflow has it, otherwise it goes to the first column.
+// BoardTemplateRequest saves a board as a template. The title pattern defaults to the board title
+// and the duration to the one of the board. Tasks are the board tasks to start new boards with,
+// all of them with include_tasks.
+type BoardTemplateRequest struct {
+	Name         string               `json:"name" validate:"required,min=4,max=100"`
+	TitlePattern string               `json:"title_pattern,omitempty" validate:"omitempty,min=4,max=200"`
+	DurationDays int                  `json:"duration_days,omitempty" validate:"omitempty,min=1,max=366"`
+	IncludeTasks bool                 `json:"include_tasks"`
+	TaskIDs      []primitive.ObjectID `json:"task_ids,omitempty" validate:"max=200"`
+}
+
+// BoardFromTemplateRequest creates a board starting on from_date from a template
+type BoardFromTemplateRequest struct {
+	FromDate time.Time `json:"from_date" validate:"required"`
+	Title    string    `json:"title,omitempty" validate:"omitempty,min=4"`
+}
+
+// BoardCloneRequest copies a board. A from_date shifts the dates of the board and of its tasks,
+// and reset_status puts the copied tasks back in the first column with their checklists undone.
+type BoardCloneRequest struct {
+	Title        string     `json:"title,omitempty" validate:"omitempty,min=4"`
+	FromDate     *time.Time `json:"from_date,omitempty"`
+	IncludeTasks bool       `json:"include_tasks"`
+	ResetStatus  bool       `json:"reset_status"`
+}
+
+// RolloverRequest rolls the unfinished tasks of a board over to the target board,
+// or to the next board of the owner when there is no target
+type RolloverRequest struct {
+	TargetBoardID *primitive.ObjectID `json:"target_board_id,omitempty"`
+	Mode          RolloverMode        `json:"mode,omitempty" validate:"omitempty,oneof=move copy"`
+}
+
+type TaskTransferRequest struct {
+	BoardID primitive.ObjectID `json:"board_id" validate:"required"`
+	Status  TaskStatus         `json:"status,omitempty"`
+}
+
+// Tasks moved or copied together to the same board
+type BulkTaskTransferRequest struct {
+	TaskIDs []primitive.ObjectID `json:"task_ids" validate:"required,min=1,max=100"`
+	BoardID primitive.ObjectID   `json:"board_id" validate:"required"`
+	Status  TaskStatus           `json:"status,omitempty"`
+}
+
+// BulkOperation is the change POST /tasks/bulk applies to every task of the request
+type BulkOperation string
+
+const (
+	BulkSetStatus    BulkOperation = "set_status"
+	BulkSetPriority  BulkOperation = "set_priority"
+	BulkAddLabels    BulkOperation = "add_labels"
+	BulkRemoveLabels BulkOperation = "remove_labels"
+	BulkAssign       BulkOperation = "assign"
+	BulkUnassign     BulkOperation = "unassign"
+	BulkMove         BulkOperation = "move"
+	BulkDelete       BulkOperation = "delete"
+)
+
+// One operation applied to many tasks, only the fields of the operation are used
+type BulkTaskRequest struct {
+	TaskIDs   []primitive.ObjectID `json:"task_ids" validate:"required,min=1,max=500"`
+	Operation BulkOperation        `json:"operation" validate:"required,oneof=set_status set_priority add_labels remove_labels assign unassign move delete"`
+	// Status is the target of set_status, and optionally the column of the moved tasks
+	Status      TaskStatus           `json:"status,omitempty" validate:"required_if=Operation set_status"`
+	Priority    TaskPriority         `json:"priority,omitempty" validate:"required_if=Operation set_priority,omitempty,oneof=LOW MEDIUM HIGH"`
+	LabelIDs    []primitive.ObjectID `json:"label_ids,omitempty" validate:"required_if=Operation add_labels,required_if=Operation remove_labels"`
+	AssigneeIDs []primitive.ObjectID `json:"assignee_ids,omitempty" validate:"required_if=Operation assign,required_if=Operation unassign"`
+	BoardID     *primitive.ObjectID  `json:"board_id,omitempty" validate:"required_if=Operation move"`
+}
+
+// Time logged by hand on a task. Without started_at the work is taken as just finished.
+// remaining_estimate_minutes replaces the remaining estimate instead of subtracting the time logged.
+type WorkLogRequest struct {
+	Minutes           int        `json:"minutes" validate:"required,min=1,max=14400"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	Note              string     `json:"note,omitempty" validate:"max=1000"`
+	RemainingEstimate *int       `json:"remaining_estimate_minutes,omitempty" validate:"omitempty,min=0"`
+}
+
+type BurndownUnit string
+
+const (
+	BurndownCount  BurndownUnit = "count"
+	BurndownPoints BurndownUnit = "points"
+)
+
+type BurndownQuery struct {
+	Unit BurndownUnit
+}
+
+// BoardArchiveFilter picks the boards of a listing by whether they are archived
+type BoardArchiveFilter string
+
+const (
+	BoardsActive   BoardArchiveFilter = "false"
+	BoardsArchived BoardArchiveFilter = "true"
+	BoardsAll      BoardArchiveFilter = "all"
+)
+
+type BoardQuery struct {
+	Archived BoardArchiveFilter
+}
+
+// ExportQuery picks the format of a board export
+type ExportQuery struct {
+	CSV bool
+}
+
+// Days of a timesheet, both included
+type TimesheetQuery struct {
+	From time.Time
+	To   time.Time
+	CSV  bool
+}
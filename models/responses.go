@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // AuthStatusResponse representa la respuesta del endpoint de verificación de autenticación
 type AuthStatusResponse struct {
@@ -15,3 +19,134 @@ type TokenResponse struct {
 	Expires time.Time `json:"expires"`
 	User    User      `json:"user"`
 }
+
+// SearchResult is a board or task matching a search, with its parent board
+type SearchResult struct {
+	Type    string  `json:"type"`
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+	Board   Board   `json:"board"`
+	Task    *Task   `json:"task,omitempty"`
+}
+
+// BoardSummary is a board with its task counts, used by the dashboard
+type BoardSummary struct {
+	Board           Board                  `json:"board"`
+	TotalTasks      int                    `json:"total_tasks"`
+	StatusCounts    map[TaskStatus]int     `json:"status_counts"`
+	CategoryCounts  map[StatusCategory]int `json:"category_counts"`
+	PriorityCounts  map[TaskPriority]int   `json:"priority_counts"`
+	CompletionRatio float64                `json:"completion_ratio"`
+	Overdue         bool                   `json:"overdue"`
+	Checklist       ChecklistProgress      `json:"checklist_progress"`
+	ColumnLoads     []ColumnLoad           `json:"column_loads"`
+	TimeTotals      TimeTotals             `json:"time_totals"`
+}
+
+// ColumnLoad is the number of tasks in a workflow column against its WIP limit
+type ColumnLoad struct {
+	Status    TaskStatus `json:"status"`
+	Load      int        `json:"load"`
+	WIPLimit  int        `json:"wip_limit,omitempty"`
+	OverLimit bool       `json:"over_limit"`
+}
+
+// BulkItemResult is the outcome of a bulk operation for one task
+type BulkItemResult struct {
+	TaskID  primitive.ObjectID `json:"task_id"`
+	Success bool               `json:"success"`
+	Error   string             `json:"error,omitempty"`
+	Task    *Task              `json:"task,omitempty"`
+}
+
+// BoardCompletion explains why a board is or is not complete
+type BoardCompletion struct {
+	Completed   bool             `json:"completed"`
+	Reason      CompletionReason `json:"reason,omitempty"`
+	Explanation string           `json:"explanation"`
+	TotalTasks  int              `json:"total_tasks"`
+	OpenTasks   int              `json:"open_tasks"`
+	Rules       CompletionRules  `json:"rules"`
+}
+
+// RolloverResult is the outcome of a rollover, with the result for every unfinished task
+type RolloverResult struct {
+	SourceBoard   *Board           `json:"source_board"`
+	TargetBoard   *Board           `json:"target_board"`
+	CreatedTarget bool             `json:"created_target"`
+	Results       []BulkItemResult `json:"results"`
+}
+
+// TimeTotals adds up the estimates and the logged time of the tasks of a board, in minutes
+type TimeTotals struct {
+	OriginalEstimate  int `json:"original_estimate_minutes" bson:"original_estimate_minutes"`
+	RemainingEstimate int `json:"remaining_estimate_minutes" bson:"remaining_estimate_minutes"`
+	Logged            int `json:"logged_minutes" bson:"logged_minutes"`
+}
+
+// TimesheetEntry is a work log of a timesheet with the task and board it was logged on
+type TimesheetEntry struct {
+	WorkLog    WorkLog `json:"work_log"`
+	TaskTitle  string  `json:"task_title"`
+	BoardTitle string  `json:"board_title"`
+}
+
+// Timesheet of a user for a range of days, with the minutes logged each day
+type Timesheet struct {
+	UserID       primitive.ObjectID `json:"user_id"`
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	TotalMinutes int                `json:"total_minutes"`
+	DailyMinutes map[string]int     `json:"daily_minutes"`
+	Entries      []TimesheetEntry   `json:"entries"`
+}
+
+// BurndownDay is the work of a board at the end of a day, or now for the current day.
+// Days still to come only have the ideal line.
+type BurndownDay struct {
+	Date      string  `json:"date"`
+	Ideal     float64 `json:"ideal"`
+	Remaining *int    `json:"remaining"`
+	Completed *int    `json:"completed"`
+	Scope     *int    `json:"scope"`
+}
+
+// ScopeChange is a task added to, removed from or resized on a board during a day
+type ScopeChange struct {
+	Date   string             `json:"date"`
+	TaskID primitive.ObjectID `json:"task_id"`
+	Title  string             `json:"title"`
+	Delta  int                `json:"delta"`
+}
+
+// Burndown of a board over its date range, in tasks or story points.
+// StartScope is the work on the board when the range started.
+type Burndown struct {
+	BoardID      primitive.ObjectID `json:"board_id"`
+	Unit         BurndownUnit       `json:"unit"`
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	StartScope   int                `json:"start_scope"`
+	Days         []BurndownDay      `json:"days"`
+	ScopeChanges []ScopeChange      `json:"scope_changes"`
+}
+
+// BoardTasks groups tasks under their board
+type BoardTasks struct {
+	Board Board  `json:"board"`
+	Tasks []Task `json:"tasks"`
+}
+
+// DependencyEdge means task From blocks task To
+type DependencyEdge struct {
+	From primitive.ObjectID `json:"from"`
+	To   primitive.ObjectID `json:"to"`
+}
+
+// DependencyGraph of a board: its tasks, the tasks on other boards linked to them, and the links
+type DependencyGraph struct {
+	Nodes []Task           `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+}
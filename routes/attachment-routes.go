@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"net/http"
+	"todoerbk/handlers"
+	"todoerbk/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+// AttachmentRouter registers the files of a task, it is mounted on the tasks router
+func AttachmentRouter(router *mux.Router, attachmentHandler *handlers.AttachmentHandler, authMiddleware *middlewares.AuthMiddleware, archiveMiddleware *middlewares.ArchiveMiddleware) {
+
+	router.Handle("/{id}/attachments",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(attachmentHandler.GetAttachments),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/attachments",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(attachmentHandler.UploadAttachment),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/attachments/{attachmentId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				http.HandlerFunc(attachmentHandler.DownloadAttachment),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/attachments/{attachmentId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(attachmentHandler.DeleteAttachment),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+}
@@ -8,11 +8,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func BoardRouter(router *mux.Router, boardHandler *handlers.BoardHandler, authMiddleware *middlewares.AuthMiddleware) {
+func BoardRouter(router *mux.Router, boardHandler *handlers.BoardHandler, authMiddleware *middlewares.AuthMiddleware, archiveMiddleware *middlewares.ArchiveMiddleware) {
 
 	router.Handle("",
 		authMiddleware.RequireAuth(
-			http.HandlerFunc(boardHandler.GetBoards),
+			middlewares.DecodePageRequest(
+				middlewares.DecodeBoardQuery(
+					http.HandlerFunc(boardHandler.GetBoards),
+				),
+			),
 		),
 	).Methods("GET")
 
@@ -29,7 +33,11 @@ func BoardRouter(router *mux.Router, boardHandler *handlers.BoardHandler, authMi
 	router.Handle("/{id}",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
-				http.HandlerFunc(boardHandler.GetBoardById),
+				middlewares.DecodePageRequest(
+					middlewares.DecodeTaskQuery(
+						http.HandlerFunc(boardHandler.GetBoardById),
+					),
+				),
 			),
 		),
 	).Methods("GET")
@@ -37,7 +45,11 @@ func BoardRouter(router *mux.Router, boardHandler *handlers.BoardHandler, authMi
 	router.Handle("/user/{id}",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
-				http.HandlerFunc(boardHandler.GetBoardsByUserId),
+				middlewares.DecodePageRequest(
+					middlewares.DecodeBoardQuery(
+						http.HandlerFunc(boardHandler.GetBoardsByUserId),
+					),
+				),
 			),
 		),
 	).Methods("GET")
@@ -47,7 +59,11 @@ func BoardRouter(router *mux.Router, boardHandler *handlers.BoardHandler, authMi
 			middlewares.DecodeBoard(
 				middlewares.ValidateBoard(
 					middlewares.ValidateModelIdFromParams(
-						http.HandlerFunc(boardHandler.UpdateBoardDetails),
+						archiveMiddleware.RejectArchivedBoard(
+							middlewares.RequireIfMatch(
+								http.HandlerFunc(boardHandler.UpdateBoardDetails),
+							),
+						),
 					),
 				),
 			),
@@ -57,17 +73,167 @@ func BoardRouter(router *mux.Router, boardHandler *handlers.BoardHandler, authMi
 	router.Handle("/{id}/status",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
-				http.HandlerFunc(boardHandler.UpdateBoardStatus),
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.RequireIfMatch(
+						http.HandlerFunc(boardHandler.UpdateBoardStatus),
+					),
+				),
 			),
 		),
 	).Methods("PUT")
 
+	router.Handle("/{id}/rollover",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.DecodeRolloverRequest(
+						http.HandlerFunc(boardHandler.RolloverBoard),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/members",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.DecodeBoardMemberRequest(
+						http.HandlerFunc(boardHandler.AddBoardMember),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/members/{userId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					http.HandlerFunc(boardHandler.RemoveBoardMember),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/archive",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(boardHandler.ArchiveBoard),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/unarchive",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(boardHandler.UnarchiveBoard),
+			),
+		),
+	).Methods("POST")
+
 	router.Handle("/{id}",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
-				http.HandlerFunc(boardHandler.DeleteBoardByID),
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.RequireIfMatch(
+						http.HandlerFunc(boardHandler.DeleteBoardByID),
+					),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/activity",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodePageRequest(
+					http.HandlerFunc(boardHandler.GetBoardActivity),
+				),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/export",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodeExportQuery(
+					http.HandlerFunc(boardHandler.ExportBoard),
+				),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/burndown",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodeBurndownQuery(
+					http.HandlerFunc(boardHandler.GetBurndown),
+				),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/dependencies",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(boardHandler.GetBoardDependencies),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/labels",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(boardHandler.GetLabels),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/labels",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.DecodeLabelRequest(
+						http.HandlerFunc(boardHandler.CreateLabel),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/labels/{labelId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.DecodeLabelRequest(
+						http.HandlerFunc(boardHandler.UpdateLabel),
+					),
+				),
+			),
+		),
+	).Methods("PUT")
+
+	router.Handle("/{id}/labels/{labelId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					http.HandlerFunc(boardHandler.DeleteLabel),
+				),
 			),
 		),
 	).Methods("DELETE")
 
+	router.Handle("/{id}/workflow",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedBoard(
+					middlewares.DecodeWorkflowRequest(
+						http.HandlerFunc(boardHandler.UpdateWorkflow),
+					),
+				),
+			),
+		),
+	).Methods("PUT")
+
 }
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"net/http"
+	"todoerbk/handlers"
+	"todoerbk/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+// CommentRouter registers the comments of a task, it is mounted on the tasks router
+func CommentRouter(router *mux.Router, commentHandler *handlers.CommentHandler, authMiddleware *middlewares.AuthMiddleware, archiveMiddleware *middlewares.ArchiveMiddleware) {
+
+	router.Handle("/{id}/comments",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodePageRequest(
+					http.HandlerFunc(commentHandler.GetComments),
+				),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/comments",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeCommentRequest(
+						http.HandlerFunc(commentHandler.CreateComment),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/comments/{commentId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeCommentRequest(
+						http.HandlerFunc(commentHandler.UpdateComment),
+					),
+				),
+			),
+		),
+	).Methods("PUT")
+
+	router.Handle("/{id}/comments/{commentId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(commentHandler.DeleteComment),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+}
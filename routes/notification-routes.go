@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net/http"
+	"todoerbk/handlers"
+	"todoerbk/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+func NotificationRouter(router *mux.Router, notificationHandler *handlers.NotificationHandler, authMiddleware *middlewares.AuthMiddleware) {
+
+	router.Handle("",
+		authMiddleware.RequireAuth(
+			middlewares.DecodePageRequest(
+				http.HandlerFunc(notificationHandler.GetNotifications),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/read",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(notificationHandler.MarkNotificationAsRead),
+			),
+		),
+	).Methods("PUT")
+
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+	"todoerbk/handlers"
+	"todoerbk/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+func SearchRouter(router *mux.Router, searchHandler *handlers.SearchHandler, authMiddleware *middlewares.AuthMiddleware) {
+
+	router.Handle("",
+		authMiddleware.RequireAuth(
+			http.HandlerFunc(searchHandler.Search),
+		),
+	).Methods("GET")
+
+}
@@ -8,11 +8,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func TaskRouter(router *mux.Router, taskHandler *handlers.TaskHandler, authMiddleware *middlewares.AuthMiddleware) {
+func TaskRouter(router *mux.Router, taskHandler *handlers.TaskHandler, authMiddleware *middlewares.AuthMiddleware, archiveMiddleware *middlewares.ArchiveMiddleware) {
 
 	router.Handle("",
 		authMiddleware.RequireAuth(
-			http.HandlerFunc(taskHandler.GetTasks),
+			middlewares.DecodePageRequest(
+				middlewares.DecodeTaskQuery(
+					http.HandlerFunc(taskHandler.GetTasks),
+				),
+			),
 		),
 	).Methods("GET")
 
@@ -26,6 +30,30 @@ func TaskRouter(router *mux.Router, taskHandler *handlers.TaskHandler, authMiddl
 		),
 	).Methods("POST")
 
+	router.Handle("/bulk",
+		authMiddleware.RequireAuth(
+			middlewares.DecodeBulkTaskRequest(
+				http.HandlerFunc(taskHandler.BulkTasks),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/transfer",
+		authMiddleware.RequireAuth(
+			middlewares.DecodeBulkTaskTransferRequest(
+				http.HandlerFunc(taskHandler.TransferTasks),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/copy",
+		authMiddleware.RequireAuth(
+			middlewares.DecodeBulkTaskTransferRequest(
+				http.HandlerFunc(taskHandler.CopyTasks),
+			),
+		),
+	).Methods("POST")
+
 	router.Handle("/{id}",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
@@ -39,7 +67,11 @@ func TaskRouter(router *mux.Router, taskHandler *handlers.TaskHandler, authMiddl
 			middlewares.DecodeTask(
 				middlewares.ValidateTask(
 					middlewares.ValidateModelIdFromParams(
-						http.HandlerFunc(taskHandler.UpdateTask),
+						archiveMiddleware.RejectArchivedTask(
+							middlewares.RequireIfMatch(
+								http.HandlerFunc(taskHandler.UpdateTask),
+							),
+						),
 					),
 				),
 			),
@@ -49,7 +81,173 @@ func TaskRouter(router *mux.Router, taskHandler *handlers.TaskHandler, authMiddl
 	router.Handle("/{id}",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
-				http.HandlerFunc(taskHandler.DeleteTaskByID),
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.RequireIfMatch(
+						http.HandlerFunc(taskHandler.DeleteTaskByID),
+					),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/activity",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodePageRequest(
+					http.HandlerFunc(taskHandler.GetTaskActivity),
+				),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/move",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeMoveTaskRequest(
+						http.HandlerFunc(taskHandler.MoveTask),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/transfer",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.RequireIfMatch(
+					middlewares.DecodeTaskTransferRequest(
+						http.HandlerFunc(taskHandler.TransferTask),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/copy",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodeTaskTransferRequest(
+					http.HandlerFunc(taskHandler.CopyTask),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/recurrence",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeRecurrenceRequest(
+						http.HandlerFunc(taskHandler.UpdateRecurrence),
+					),
+				),
+			),
+		),
+	).Methods("PUT")
+
+	router.Handle("/{id}/recurrence",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(taskHandler.StopRecurrence),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/recurrence/skip",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(taskHandler.SkipOccurrence),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/checklist",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeChecklistItemRequest(
+						http.HandlerFunc(taskHandler.AddChecklistItem),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/checklist/order",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeChecklistOrderRequest(
+						http.HandlerFunc(taskHandler.ReorderChecklist),
+					),
+				),
+			),
+		),
+	).Methods("PUT")
+
+	router.Handle("/{id}/checklist/{itemId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeChecklistItemUpdateRequest(
+						http.HandlerFunc(taskHandler.UpdateChecklistItem),
+					),
+				),
+			),
+		),
+	).Methods("PUT")
+
+	router.Handle("/{id}/checklist/{itemId}/toggle",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(taskHandler.ToggleChecklistItem),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/checklist/{itemId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(taskHandler.RemoveChecklistItem),
+				),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/dependencies",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(taskHandler.GetTaskDependencies),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/dependencies",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeDependencyRequest(
+						http.HandlerFunc(taskHandler.AddDependency),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/dependencies/{blockerId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(taskHandler.RemoveDependency),
+				),
 			),
 		),
 	).Methods("DELETE")
@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"net/http"
+	"todoerbk/handlers"
+	"todoerbk/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+// TemplateRouter registers the board templates of the user
+func TemplateRouter(router *mux.Router, templateHandler *handlers.TemplateHandler, authMiddleware *middlewares.AuthMiddleware) {
+
+	router.Handle("",
+		authMiddleware.RequireAuth(
+			middlewares.DecodePageRequest(
+				http.HandlerFunc(templateHandler.GetTemplates),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(templateHandler.GetTemplateById),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(templateHandler.DeleteTemplate),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/boards",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodeBoardFromTemplateRequest(
+					http.HandlerFunc(templateHandler.CreateBoardFromTemplate),
+				),
+			),
+		),
+	).Methods("POST")
+}
+
+// BoardTemplateRouter registers saving a board as a template and cloning it, it is mounted on the boards router
+func BoardTemplateRouter(router *mux.Router, templateHandler *handlers.TemplateHandler, authMiddleware *middlewares.AuthMiddleware) {
+
+	router.Handle("/{id}/template",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodeBoardTemplateRequest(
+					http.HandlerFunc(templateHandler.SaveBoardAsTemplate),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/clone",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodeBoardCloneRequest(
+					http.HandlerFunc(templateHandler.CloneBoard),
+				),
+			),
+		),
+	).Methods("POST")
+}
@@ -10,6 +10,16 @@ import (
 
 func UserRouter(router *mux.Router, userHandler *handlers.UserHandler, authMiddleware *middlewares.AuthMiddleware) {
 
+	router.Handle("/me/tasks",
+		authMiddleware.RequireAuth(
+			middlewares.DecodePageRequest(
+				middlewares.DecodeTaskQuery(
+					http.HandlerFunc(userHandler.GetMyTasks),
+				),
+			),
+		),
+	).Methods("GET")
+
 	router.Handle("/{id}",
 		authMiddleware.RequireAuth(
 			middlewares.ValidateModelIdFromParams(
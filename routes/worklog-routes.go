@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"net/http"
+	"todoerbk/handlers"
+	"todoerbk/middlewares"
+
+	"github.com/gorilla/mux"
+)
+
+// WorkLogRouter registers the timers and work logs of a task, it is mounted on the tasks router
+func WorkLogRouter(router *mux.Router, workLogHandler *handlers.WorkLogHandler, authMiddleware *middlewares.AuthMiddleware, archiveMiddleware *middlewares.ArchiveMiddleware) {
+
+	router.Handle("/{id}/timer",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(workLogHandler.StartTimer),
+				),
+			),
+		),
+	).Methods("POST")
+
+	// A timer left running when its board was archived can still be stopped
+	router.Handle("/{id}/timer",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				http.HandlerFunc(workLogHandler.StopTimer),
+			),
+		),
+	).Methods("DELETE")
+
+	router.Handle("/{id}/worklogs",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				middlewares.DecodePageRequest(
+					http.HandlerFunc(workLogHandler.GetWorkLogs),
+				),
+			),
+		),
+	).Methods("GET")
+
+	router.Handle("/{id}/worklogs",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					middlewares.DecodeWorkLogRequest(
+						http.HandlerFunc(workLogHandler.LogWork),
+					),
+				),
+			),
+		),
+	).Methods("POST")
+
+	router.Handle("/{id}/worklogs/{workLogId}",
+		authMiddleware.RequireAuth(
+			middlewares.ValidateModelIdsFromParams(
+				archiveMiddleware.RejectArchivedTask(
+					http.HandlerFunc(workLogHandler.DeleteWorkLog),
+				),
+			),
+		),
+	).Methods("DELETE")
+}
+
+// TimesheetRouter registers the timer and the timesheet of the current user, it is mounted on the users router
+func TimesheetRouter(router *mux.Router, workLogHandler *handlers.WorkLogHandler, authMiddleware *middlewares.AuthMiddleware) {
+
+	router.Handle("/me/timer",
+		authMiddleware.RequireAuth(
+			http.HandlerFunc(workLogHandler.GetMyTimer),
+		),
+	).Methods("GET")
+
+	router.Handle("/me/timesheet",
+		authMiddleware.RequireAuth(
+			middlewares.DecodeTimesheetQuery(
+				http.HandlerFunc(workLogHandler.GetMyTimesheet),
+			),
+		),
+	).Methods("GET")
+}
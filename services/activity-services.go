@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// untrackedFields change on every write or are derived from other fields, they are left out of diffs
+var untrackedFields = map[string]bool{
+	"_id":                true,
+	"created_at":         true,
+	"updated_at":         true,
+	"version":            true,
+	"checklist_progress": true,
+}
+
+// ActivityService stores the history of tasks and boards. The collection must decode
+// embedded documents as maps so the recorded values read back as plain JSON objects.
+type ActivityService struct {
+	db *mongo.Collection
+}
+
+func NewActivityService(db *mongo.Collection) *ActivityService {
+	return &ActivityService{db: db}
+}
+
+// EnsureIndexes creates the indexes backing the task and board feeds
+func (s *ActivityService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "board_ids", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+// storedFields returns the document stored for v, empty for nil
+func storedFields[T any](v *T) bson.M {
+	doc := bson.M{}
+	if v == nil {
+		return doc
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return doc
+	}
+	bson.Unmarshal(raw, &doc)
+	return doc
+}
+
+// diffFields lists the stored fields that differ between before and after, by field name.
+// A nil before lists every field of after, as a creation does, and a nil after the opposite.
+func diffFields[T any](before, after *T) []models.FieldChange {
+	old, new := storedFields(before), storedFields(after)
+	fields := []string{}
+	for field := range old {
+		fields = append(fields, field)
+	}
+	for field := range new {
+		if _, ok := old[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []models.FieldChange{}
+	for _, field := range fields {
+		if untrackedFields[field] || reflect.DeepEqual(old[field], new[field]) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: field, Before: old[field], After: new[field]})
+	}
+	return changes
+}
+
+// TaskActivity describes the change of a task from before to after, nil before for a creation
+// and nil after for a delete. It returns nil when nothing tracked changed.
+func TaskActivity(actorID primitive.ObjectID, before, after *models.Task) *models.Activity {
+	changes := diffFields(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	activity := &models.Activity{ActorID: actorID, Changes: changes}
+	current := after
+	switch {
+	case before == nil:
+		activity.Action = models.ActivityCreated
+	case after == nil:
+		activity.Action = models.ActivityDeleted
+		current = before
+	case before.BoardID != after.BoardID:
+		activity.Action = models.ActivityMoved
+		activity.BoardIDs = []primitive.ObjectID{before.BoardID}
+	case before.Status != after.Status:
+		activity.Action = models.ActivityStatusChanged
+	default:
+		activity.Action = models.ActivityUpdated
+	}
+	activity.TaskID = &current.ID
+	activity.BoardID = current.BoardID
+	activity.BoardIDs = append(activity.BoardIDs, current.BoardID)
+	return activity
+}
+
+// BoardActivity describes the change of a board from before to after, like TaskActivity
+func BoardActivity(actorID primitive.ObjectID, before, after *models.Board) *models.Activity {
+	changes := diffFields(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	activity := &models.Activity{ActorID: actorID, Action: models.ActivityUpdated, Changes: changes}
+	current := after
+	switch {
+	case before == nil:
+		activity.Action = models.ActivityCreated
+	case after == nil:
+		activity.Action = models.ActivityDeleted
+		current = before
+	}
+	activity.BoardID = current.ID
+	activity.BoardIDs = []primitive.ObjectID{current.ID}
+	return activity
+}
+
+func (s *ActivityService) Record(ctx context.Context, activity *models.Activity) error {
+	activity.ID = primitive.NewObjectID()
+	activity.CreatedAt = time.Now().UTC()
+	_, err := s.db.InsertOne(ctx, activity)
+	return err
+}
+
+func activityPageKey(activity models.Activity) (interface{}, primitive.ObjectID) {
+	return activity.CreatedAt, activity.ID
+}
+
+// GetActivityByTaskId returns one page of the history of the task, oldest first
+func (s *ActivityService) GetActivityByTaskId(ctx context.Context, taskID string, page models.PageRequest) ([]models.Activity, string, error) {
+	objID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, "", errors.New("invalid task id")
+	}
+	return findPage(ctx, s.db, bson.M{"task_id": objID}, page, activityPageKey)
+}
+
+// GetActivityByBoardId returns one page of the history of the board and its tasks, oldest first
+func (s *ActivityService) GetActivityByBoardId(ctx context.Context, boardID string, page models.PageRequest) ([]models.Activity, string, error) {
+	objID, err := primitive.ObjectIDFromHex(boardID)
+	if err != nil {
+		return nil, "", errors.New("invalid board id")
+	}
+	return findPage(ctx, s.db, bson.M{"board_ids": objID}, page, activityPageKey)
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+var ErrAttachmentTooLarge = errors.New("the file exceeds the maximum attachment size")
+var ErrEmptyAttachment = errors.New("the file is empty")
+var ErrQuotaExceeded = errors.New("the file exceeds your storage quota")
+
+const defaultMaxAttachmentMB = 10
+const defaultAttachmentQuotaMB = 100
+
+// AttachmentService stores the files of tasks in the blob store and their metadata in the task.
+// The bytes uploaded by each user are counted in usage to enforce the quota.
+type AttachmentService struct {
+	usage       *mongo.Collection
+	TaskService *TaskService
+	Store       BlobStore
+	MaxFileSize int64
+	UserQuota   int64
+}
+
+func NewAttachmentService(usage *mongo.Collection, taskService *TaskService, store BlobStore) *AttachmentService {
+	return &AttachmentService{
+		usage:       usage,
+		TaskService: taskService,
+		Store:       store,
+		MaxFileSize: megabytesFromEnv("ATTACHMENT_MAX_SIZE_MB", defaultMaxAttachmentMB),
+		UserQuota:   megabytesFromEnv("ATTACHMENT_QUOTA_MB", defaultAttachmentQuotaMB),
+	}
+}
+
+func megabytesFromEnv(name string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || value <= 0 {
+		value = fallback
+	}
+	return value << 20
+}
+
+// sanitizeFilename keeps the base name of the uploaded file without control characters or quotes
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// Upload saves the file and adds its metadata to the task. The content type is sniffed
+// from the bytes, the name sent by the client is never trusted for it.
+func (s *AttachmentService) Upload(ctx context.Context, taskID string, uploaderID primitive.ObjectID, filename string, content io.Reader) (*models.Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(content, s.MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.MaxFileSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyAttachment
+	}
+
+	if err := s.reserve(ctx, uploaderID, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	attachment := models.Attachment{
+		ID:          primitive.NewObjectID(),
+		Filename:    sanitizeFilename(filename),
+		ContentType: http.DetectContentType(data),
+		Size:        int64(len(data)),
+		Checksum:    hex.EncodeToString(sum[:]),
+		UploaderID:  uploaderID,
+		UploadedAt:  time.Now().UTC(),
+	}
+	attachment.StorageKey = "tasks/" + taskID + "/" + attachment.ID.Hex()
+
+	if err := s.Store.Put(ctx, attachment.StorageKey, data, attachment.ContentType); err != nil {
+		s.release(ctx, uploaderID, attachment.Size)
+		return nil, err
+	}
+
+	_, err = s.TaskService.modifyTask(ctx, taskID, func(task *models.Task) error {
+		task.Attachments = append(task.Attachments, attachment)
+		return nil
+	})
+	if err != nil {
+		s.DeleteBlobs(ctx, []models.Attachment{attachment})
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func findAttachment(task *models.Task, attachmentID primitive.ObjectID) int {
+	for i, attachment := range task.Attachments {
+		if attachment.ID == attachmentID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Open returns the metadata and the content of an attachment of the task
+func (s *AttachmentService) Open(ctx context.Context, task *models.Task, attachmentID primitive.ObjectID) (*models.Attachment, io.ReadCloser, error) {
+	i := findAttachment(task, attachmentID)
+	if i < 0 {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	attachment := task.Attachments[i]
+	content, err := s.Store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &attachment, content, nil
+}
+
+// Remove drops the attachment from the task and then deletes its blob
+func (s *AttachmentService) Remove(ctx context.Context, taskID string, attachmentID primitive.ObjectID) (*models.Task, error) {
+	var removed models.Attachment
+	task, err := s.TaskService.modifyTask(ctx, taskID, func(task *models.Task) error {
+		i := findAttachment(task, attachmentID)
+		if i < 0 {
+			return ErrAttachmentNotFound
+		}
+		removed = task.Attachments[i]
+		task.Attachments = append(task.Attachments[:i], task.Attachments[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.DeleteBlobs(ctx, []models.Attachment{removed})
+	return task, nil
+}
+
+// DeleteBlobs removes the content of attachments whose metadata is already gone and gives their
+// bytes back to the quota of the uploaders.
+// It is best effort: a blob left behind only wastes space, so failures are logged.
+func (s *AttachmentService) DeleteBlobs(ctx context.Context, attachments []models.Attachment) {
+	for _, attachment := range attachments {
+		if err := s.Store.Delete(ctx, attachment.StorageKey); err != nil {
+			log.Printf("Error deleting blob %s: %v", attachment.StorageKey, err)
+		}
+		s.release(ctx, attachment.UploaderID, attachment.Size)
+	}
+}
+
+// reserve adds size to the bytes used by the uploader unless that goes over the quota.
+// The check and the increment are one conditional update, so concurrent uploads cannot both pass it.
+func (s *AttachmentService) reserve(ctx context.Context, uploaderID primitive.ObjectID, size int64) error {
+	if err := s.ensureUsage(ctx, uploaderID); err != nil {
+		return err
+	}
+	result, err := s.usage.UpdateOne(ctx,
+		bson.M{"_id": uploaderID, "bytes": bson.M{"$lte": s.UserQuota - size}},
+		bson.M{"$inc": bson.M{"bytes": size}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// release gives size back to the quota of the uploader, failures are logged
+func (s *AttachmentService) release(ctx context.Context, uploaderID primitive.ObjectID, size int64) {
+	_, err := s.usage.UpdateOne(ctx,
+		bson.M{"_id": uploaderID},
+		bson.M{"$inc": bson.M{"bytes": -size}},
+	)
+	if err != nil {
+		log.Printf("Error releasing %d attachment bytes of user %s: %v", size, uploaderID.Hex(), err)
+	}
+}
+
+// ensureUsage starts the counter of the uploader from the attachments stored before it existed.
+// Uploads only count once the counter exists, so the sum cannot miss any of them.
+func (s *AttachmentService) ensureUsage(ctx context.Context, uploaderID primitive.ObjectID) error {
+	err := s.usage.FindOne(ctx, bson.M{"_id": uploaderID}).Err()
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	used, err := s.TaskService.attachmentBytesByUploader(ctx, uploaderID)
+	if err != nil {
+		return err
+	}
+	_, err = s.usage.InsertOne(ctx, bson.M{"_id": uploaderID, "bytes": used})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// GetAttachmentsByBoardId returns the attachments of every task of the board, used to clean up their blobs
+func (s *TaskService) GetAttachmentsByBoardId(ctx context.Context, boardId string) ([]models.Attachment, error) {
+	objID, err := primitive.ObjectIDFromHex(boardId)
+	if err != nil {
+		return nil, errors.New("invalid board ID format")
+	}
+	tasks, err := s.findAll(ctx, bson.M{"board_id": objID, "attachments.0": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	attachments := []models.Attachment{}
+	for _, task := range tasks {
+		attachments = append(attachments, task.Attachments...)
+	}
+	return attachments, nil
+}
+
+// attachmentBytesByUploader sums the size of every attachment uploaded by the user
+func (s *TaskService) attachmentBytesByUploader(ctx context.Context, uploaderID primitive.ObjectID) (int64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"attachments.uploader_id": uploaderID}},
+		{"$unwind": "$attachments"},
+		{"$match": bson.M{"attachments.uploader_id": uploaderID}},
+		{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$attachments.size"}}},
+	}
+	cursor, err := s.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Total, nil
+}
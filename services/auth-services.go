@@ -7,9 +7,7 @@ import (
 	"fmt"
 	"log"
 	"math/big"
-	"net/smtp"
 	"os"
-	"strings"
 	"time"
 	"todoerbk/models"
 
@@ -19,14 +17,10 @@ import (
 )
 
 type AuthService struct {
-	UserService  *UserService
-	jwtSecret    []byte
-	jwtDuration  time.Duration
-	smtpHost     string
-	smtpPort     string
-	smtpUsername string
-	smtpPassword string
-	fromEmail    string
+	UserService *UserService
+	Mailer      *Mailer
+	jwtSecret   []byte
+	jwtDuration time.Duration
 }
 
 const (
@@ -34,34 +28,12 @@ const (
 	resetCodeExpiration = 15 * time.Minute
 )
 
-func NewAuthService(userService *UserService) *AuthService {
-	smtpHost := os.Getenv("SMTP_HOST")
-	if smtpHost == "" {
-		smtpHost = "smtp.gmail.com"
-	}
-
-	smtpPort := os.Getenv("SMTP_PORT")
-	if smtpPort == "" {
-		smtpPort = "587"
-	}
-
-	smtpUsername := os.Getenv("SMTP_USERNAME")
-	smtpPassword := os.Getenv("SMTP_PASSWORD")
-	fromEmail := os.Getenv("FROM_EMAIL")
-
-	if smtpUsername == "" || smtpPassword == "" {
-		log.Println("WARNING: SMTP credentials not set, email features will be disabled")
-	}
-
+func NewAuthService(userService *UserService, mailer *Mailer) *AuthService {
 	return &AuthService{
-		UserService:  userService,
-		jwtSecret:    []byte(os.Getenv("JWT_SECRET")),
-		jwtDuration:  24 * time.Hour,
-		smtpHost:     smtpHost,
-		smtpPort:     smtpPort,
-		smtpUsername: smtpUsername,
-		smtpPassword: smtpPassword,
-		fromEmail:    fromEmail,
+		UserService: userService,
+		Mailer:      mailer,
+		jwtSecret:   []byte(os.Getenv("JWT_SECRET")),
+		jwtDuration: 24 * time.Hour,
 	}
 }
 
@@ -239,23 +211,11 @@ func (s *AuthService) ResetPassword(ctx context.Context, code, newPassword strin
 }
 
 func (s *AuthService) sendResetEmail(toEmail, resetCode string) error {
-	if s.smtpUsername == "" || s.smtpPassword == "" {
+	if !s.Mailer.Enabled() {
 		log.Printf("Email sending disabled. Reset code for %s: %s", toEmail, resetCode)
 		return nil
 	}
 
-	auth := smtp.PlainAuth("", s.smtpUsername, s.smtpPassword, s.smtpHost)
-
-	// Definir los headers y el contenido separadamente
-	headers := []string{
-		"From: KNBNN application",
-		"To: " + toEmail,
-		"Subject: Código de recuperación de contraseña KNBNN app",
-		"MIME-Version: 1.0",
-		"Content-Type: text/html; charset=UTF-8",
-		"", // Línea en blanco necesaria entre headers y contenido
-	}
-
 	htmlBody := `
 <html>
 <body>
@@ -267,21 +227,7 @@ func (s *AuthService) sendResetEmail(toEmail, resetCode string) error {
 </body>
 </html>`
 
-	message := strings.Join(headers, "\r\n") + "\r\n" + fmt.Sprintf(htmlBody, resetCode)
-
-	err := smtp.SendMail(
-		s.smtpHost+":"+s.smtpPort,
-		auth,
-		s.fromEmail,
-		[]string{toEmail},
-		[]byte(message),
-	)
-
-	if err != nil {
-		return fmt.Errorf("error sending email: %v", err)
-	}
-
-	return nil
+	return s.Mailer.Send(toEmail, "Código de recuperación de contraseña KNBNN app", fmt.Sprintf(htmlBody, resetCode))
 }
 
 func (s *AuthService) CheckAuthStatus(ctx context.Context, userID string) (*models.AuthStatusResponse, error) {
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := "tasks/task1/attachment1"
+
+	if err := store.Put(ctx, key, []byte("first"), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put(ctx, key, []byte("second"), "text/plain"); err != nil {
+		t.Fatalf("Put over an existing blob: %v", err)
+	}
+
+	content, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "second" {
+		t.Errorf("Get = %q, want %q", data, "second")
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing blob: %v", err)
+	}
+}
+
+func TestLocalBlobStoreRejectsKeysOutsideRoot(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"../escape", "/etc/passwd", ".", "tasks/../../escape"} {
+		if err := store.Put(context.Background(), key, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded, want an invalid key error", key)
+		}
+	}
+}
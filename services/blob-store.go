@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the content of uploaded files, addressed by a key like tasks/<taskId>/<attachmentId>
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore builds the store selected by BLOB_STORE, "local" (default) or "s3"
+func NewBlobStore() (BlobStore, error) {
+	switch os.Getenv("BLOB_STORE") {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalBlobStore(dir)
+	case "s3":
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return NewS3BlobStore(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_BUCKET"),
+			region,
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+		)
+	default:
+		return nil, errors.New("unknown BLOB_STORE " + os.Getenv("BLOB_STORE"))
+	}
+}
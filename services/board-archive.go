@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var ErrBoardArchived = errors.New("the board is archived, unarchive it before changing it or its tasks")
+
+// archiveFilter adds to the filter of a board listing which boards it returns by their archive
+func archiveFilter(filter bson.M, archived models.BoardArchiveFilter) bson.M {
+	switch archived {
+	case models.BoardsAll:
+	case models.BoardsArchived:
+		filter["archived"] = true
+	default:
+		// Boards stored before archiving existed have no archived field
+		filter["archived"] = bson.M{"$ne": true}
+	}
+	return filter
+}
+
+// SetArchived archives or unarchives the board, and reports whether it changed. Archiving leaves
+// Completed as it is: a finished board can stay in the listings and an open one can be archived.
+func (s *BoardService) SetArchived(ctx context.Context, id string, archived bool) (*models.Board, bool, error) {
+	board, err := s.modifyBoard(ctx, id, func(board *models.Board) error {
+		if board.Archived == archived {
+			return errUnchanged
+		}
+		board.Archived = archived
+		board.ArchivedAt = nil
+		if archived {
+			now := time.Now().UTC()
+			board.ArchivedAt = &now
+		}
+		return nil
+	})
+	if errors.Is(err, errUnchanged) {
+		board, err = s.GetBoardById(ctx, id)
+		return board, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return board, true, nil
+}
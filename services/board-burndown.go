@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BurndownService rebuilds the daily work of a board from its tasks and their activity
+type BurndownService struct {
+	TaskService     *TaskService
+	ActivityService *ActivityService
+}
+
+func NewBurndownService(taskService *TaskService, activityService *ActivityService) *BurndownService {
+	return &BurndownService{TaskService: taskService, ActivityService: activityService}
+}
+
+// GetTaskHistorySince returns, newest first, the activity since the date of every task that was on
+// the board since then, including what happened to them on other boards
+func (s *ActivityService) GetTaskHistorySince(ctx context.Context, boardID primitive.ObjectID, since time.Time) ([]models.Activity, error) {
+	taskIDs, err := s.db.Distinct(ctx, "task_id", bson.M{
+		"board_ids":  boardID,
+		"task_id":    bson.M{"$exists": true},
+		"created_at": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return nil, err
+	}
+	activity := []models.Activity{}
+	if len(taskIDs) == 0 {
+		return activity, nil
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := s.db.Find(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}, "created_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &activity); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// burndownTask is what the burndown needs to know of a task at some point in time
+type burndownTask struct {
+	exists  bool
+	boardID primitive.ObjectID
+	done    bool
+	points  int
+	title   string
+}
+
+func newBurndownTask(task models.Task) *burndownTask {
+	return &burndownTask{
+		exists:  true,
+		boardID: task.BoardID,
+		done:    task.StatusCategory == models.CategoryDone,
+		points:  task.StoryPoints,
+		title:   task.Title,
+	}
+}
+
+// undo puts the task back in the state it had before the activity
+func (t *burndownTask) undo(activity models.Activity) {
+	switch activity.Action {
+	case models.ActivityCreated:
+		t.exists = false
+		return
+	case models.ActivityDeleted:
+		t.exists = true
+	}
+	for _, change := range activity.Changes {
+		switch change.Field {
+		case "board_id":
+			t.boardID, _ = change.Before.(primitive.ObjectID)
+		case "status_category":
+			category, _ := change.Before.(string)
+			t.done = category == string(models.CategoryDone)
+		case "story_points":
+			t.points = storedInt(change.Before)
+		case "title":
+			t.title, _ = change.Before.(string)
+		}
+	}
+}
+
+// storedInt reads a number decoded from the activity, zero when it was not stored
+func storedInt(value interface{}) int {
+	switch n := value.(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+// burndownSnapshot is the work of each task on the board at some point in time
+type burndownSnapshot struct {
+	scope     int
+	completed int
+	work      map[primitive.ObjectID]int
+	titles    map[primitive.ObjectID]string
+}
+
+func takeSnapshot(tasks map[primitive.ObjectID]*burndownTask, boardID primitive.ObjectID, unit models.BurndownUnit, at time.Time) burndownSnapshot {
+	snapshot := burndownSnapshot{work: map[primitive.ObjectID]int{}, titles: map[primitive.ObjectID]string{}}
+	for id, task := range tasks {
+		// The occurrences created by the recurrence scheduler have no creation in the activity,
+		// the time in their id tells when they appeared
+		if !task.exists || task.boardID != boardID || !id.Timestamp().Before(at) {
+			continue
+		}
+		work := 1
+		if unit == models.BurndownPoints {
+			work = task.points
+		}
+		snapshot.work[id] = work
+		snapshot.titles[id] = task.title
+		snapshot.scope += work
+		if task.done {
+			snapshot.completed += work
+		}
+	}
+	return snapshot
+}
+
+// GetBurndown returns the daily remaining and completed work of the board over its date range,
+// the ideal line and the changes of scope. It starts from the tasks as they are now and walks
+// their activity backwards to rebuild the board at the end of each past day.
+func (s *BurndownService) GetBurndown(ctx context.Context, board *models.Board, unit models.BurndownUnit, now time.Time) (*models.Burndown, error) {
+	history, err := s.ActivityService.GetTaskHistorySince(ctx, board.ID, board.FromDate.UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.TaskService.findAll(ctx, bson.M{"board_id": board.ID})
+	if err != nil {
+		return nil, err
+	}
+	onBoard := map[primitive.ObjectID]bool{}
+	for _, task := range tasks {
+		onBoard[task.ID] = true
+	}
+	elsewhere := []primitive.ObjectID{}
+	for _, activity := range history {
+		if !onBoard[*activity.TaskID] {
+			onBoard[*activity.TaskID] = true
+			elsewhere = append(elsewhere, *activity.TaskID)
+		}
+	}
+	if len(elsewhere) > 0 {
+		moved, err := s.TaskService.GetTasksByIds(ctx, elsewhere)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, moved...)
+	}
+	return buildBurndown(board, unit, now, tasks, history), nil
+}
+
+// buildBurndown rewinds the tasks through their history, newest first
+func buildBurndown(board *models.Board, unit models.BurndownUnit, now time.Time, current []models.Task, history []models.Activity) *models.Burndown {
+	first := board.FromDate.UTC().Truncate(24 * time.Hour)
+	last := board.ToDate.UTC().Truncate(24 * time.Hour)
+	days := int(last.Sub(first)/(24*time.Hour)) + 1
+
+	tasks := map[primitive.ObjectID]*burndownTask{}
+	for _, task := range current {
+		tasks[task.ID] = newBurndownTask(task)
+	}
+	for _, activity := range history {
+		if _, ok := tasks[*activity.TaskID]; !ok {
+			// Tasks that no longer exist are rebuilt from their delete
+			tasks[*activity.TaskID] = &burndownTask{}
+		}
+	}
+
+	// snapshots[0] is the start of the range, snapshots[i+1] the end of day i
+	snapshots := make([]*burndownSnapshot, days+1)
+	next := 0
+	rewind := func(at time.Time) *burndownSnapshot {
+		for next < len(history) && !history[next].CreatedAt.Before(at) {
+			tasks[*history[next].TaskID].undo(history[next])
+			next++
+		}
+		snapshot := takeSnapshot(tasks, board.ID, unit, at)
+		return &snapshot
+	}
+	for i := days; i >= 0; i-- {
+		at := first.Add(time.Duration(i) * 24 * time.Hour)
+		if at.After(now) {
+			// Days to come are left empty, and a range that has not started yet plans the work on the board now
+			if i > 0 && at.Add(-24*time.Hour).After(now) {
+				continue
+			}
+			at = now
+		}
+		snapshots[i] = rewind(at)
+	}
+
+	burndown := &models.Burndown{
+		BoardID:      board.ID,
+		Unit:         unit,
+		From:         first.Format("2006-01-02"),
+		To:           last.Format("2006-01-02"),
+		StartScope:   snapshots[0].scope,
+		Days:         []models.BurndownDay{},
+		ScopeChanges: []models.ScopeChange{},
+	}
+	for i := 0; i < days; i++ {
+		date := first.Add(time.Duration(i) * 24 * time.Hour).Format("2006-01-02")
+		day := models.BurndownDay{
+			Date:  date,
+			Ideal: float64(burndown.StartScope) * float64(days-i-1) / float64(days),
+		}
+		if snapshot := snapshots[i+1]; snapshot != nil {
+			remaining := snapshot.scope - snapshot.completed
+			day.Remaining, day.Completed, day.Scope = &remaining, &snapshot.completed, &snapshot.scope
+			burndown.ScopeChanges = append(burndown.ScopeChanges, scopeChanges(date, snapshots[i], snapshot)...)
+		}
+		burndown.Days = append(burndown.Days, day)
+	}
+	return burndown
+}
+
+// scopeChanges lists the tasks whose work on the board differs between two snapshots
+func scopeChanges(date string, before, after *burndownSnapshot) []models.ScopeChange {
+	changes := []models.ScopeChange{}
+	for id, work := range after.work {
+		if delta := work - before.work[id]; delta != 0 {
+			changes = append(changes, models.ScopeChange{Date: date, TaskID: id, Title: after.titles[id], Delta: delta})
+		}
+	}
+	for id, work := range before.work {
+		if _, ok := after.work[id]; !ok && work != 0 {
+			changes = append(changes, models.ScopeChange{Date: date, TaskID: id, Title: before.titles[id], Delta: -work})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].TaskID.Hex() < changes[j].TaskID.Hex()
+	})
+	return changes
+}
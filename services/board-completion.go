@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrOpenTasks = errors.New("the board still has open tasks, finish them first or retry with ?force=true")
+
+// errUnchanged stops a board modification that turned out not to be needed
+var errUnchanged = errors.New("board unchanged")
+
+// CompletionService applies the completion rules of the boards as their tasks change
+type CompletionService struct {
+	BoardService    *BoardService
+	TaskService     *TaskService
+	ActivityService *ActivityService
+}
+
+func NewCompletionService(boardService *BoardService, taskService *TaskService, activityService *ActivityService) *CompletionService {
+	return &CompletionService{BoardService: boardService, TaskService: taskService, ActivityService: activityService}
+}
+
+// CountOpenTasks returns how many tasks the board has and how many of them are not done
+func (s *TaskService) CountOpenTasks(ctx context.Context, boardID primitive.ObjectID) (int, int, error) {
+	total, err := s.db.CountDocuments(ctx, bson.M{"board_id": boardID})
+	if err != nil {
+		return 0, 0, err
+	}
+	open, err := s.db.CountDocuments(ctx, bson.M{"board_id": boardID, "status_category": bson.M{"$ne": models.CategoryDone}})
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(total), int(open), nil
+}
+
+// Explain tells why the board is or is not complete
+func (s *CompletionService) Explain(ctx context.Context, board *models.Board) (*models.BoardCompletion, error) {
+	total, open, err := s.TaskService.CountOpenTasks(ctx, board.ID)
+	if err != nil {
+		return nil, err
+	}
+	completion := &models.BoardCompletion{
+		Completed:  board.Completed,
+		Reason:     board.CompletionReason,
+		TotalTasks: total,
+		OpenTasks:  open,
+		Rules:      board.CompletionRules,
+	}
+	tasks := strconv.Itoa(total-open) + " of " + strconv.Itoa(total) + " tasks are done"
+
+	switch {
+	case board.Completed && board.CompletionReason == models.CompletedAllDone:
+		completion.Explanation = "Completed automatically when all its tasks were done"
+	case board.Completed && board.CompletionReason == models.CompletedRolledOver:
+		completion.Explanation = "Completed when its unfinished tasks were rolled over"
+	case board.Completed && board.CompletionReason == models.CompletedForced:
+		completion.Explanation = "Completed by hand while some tasks were still open, " + tasks
+	case board.Completed:
+		completion.Explanation = "Completed by hand, " + tasks
+	case board.CompletionReason == models.ReopenedTaskAdded:
+		completion.Explanation = "Reopened automatically when a task was added, " + tasks
+	case board.CompletionReason == models.ReopenedTaskReopened:
+		completion.Explanation = "Reopened automatically when a task was reopened, " + tasks
+	case board.CompletionReason == models.ReopenedManually:
+		completion.Explanation = "Reopened by hand, " + tasks
+	case total == 0:
+		completion.Explanation = "Open, the board has no tasks yet"
+	case open > 0:
+		completion.Explanation = "Open, " + tasks
+	case board.CompletionRules.AutoComplete:
+		completion.Explanation = "Open, all tasks are done and auto-complete applies the next time a task is done"
+	default:
+		completion.Explanation = "Open, all tasks are done but the board is completed by hand"
+	}
+	return completion, nil
+}
+
+// CheckManualCompletion refuses to complete by hand a board with open tasks when its rules require them done
+func (s *CompletionService) CheckManualCompletion(ctx context.Context, board *models.Board, force bool) (models.CompletionReason, error) {
+	_, open, err := s.TaskService.CountOpenTasks(ctx, board.ID)
+	if err != nil {
+		return "", err
+	}
+	if open == 0 {
+		return models.CompletedManually, nil
+	}
+	if board.CompletionRules.RequireTasksDone && !force {
+		return "", ErrOpenTasks
+	}
+	return models.CompletedForced, nil
+}
+
+// TaskChanged applies the completion rules to the boards of a task that changed from before to after,
+// nil before for a creation and nil after for a delete. The change already happened, so a failure is
+// only logged.
+func (s *CompletionService) TaskChanged(ctx context.Context, actorID primitive.ObjectID, before, after *models.Task) {
+	isOpen := func(task *models.Task) bool {
+		return task != nil && task.StatusCategory != models.CategoryDone
+	}
+	arrived := after != nil && (before == nil || before.BoardID != after.BoardID)
+
+	var err error
+	switch {
+	case isOpen(after) && arrived:
+		err = s.reopen(ctx, actorID, after.BoardID, models.ReopenedTaskAdded)
+	case isOpen(after) && !isOpen(before):
+		err = s.reopen(ctx, actorID, after.BoardID, models.ReopenedTaskReopened)
+	case after != nil && arrived:
+		err = s.CompleteIfDone(ctx, actorID, after.BoardID)
+	}
+	if err != nil {
+		log.Printf("Error applying completion rules of board %s: %v", after.BoardID.Hex(), err)
+	}
+
+	if isOpen(before) && (!isOpen(after) || after.BoardID != before.BoardID) {
+		if err := s.CompleteIfDone(ctx, actorID, before.BoardID); err != nil {
+			log.Printf("Error applying completion rules of board %s: %v", before.BoardID.Hex(), err)
+		}
+	}
+}
+
+// setCompleted saves the completion of the board and records it, unless change leaves it as it is
+func (s *CompletionService) setCompleted(ctx context.Context, actorID, boardID primitive.ObjectID, change func(*models.Board) bool) error {
+	var before models.Board
+	board, err := s.BoardService.modifyBoard(ctx, boardID.Hex(), func(board *models.Board) error {
+		before = *board
+		if !change(board) {
+			return errUnchanged
+		}
+		return nil
+	})
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.ActivityService.Record(ctx, BoardActivity(actorID, &before, board)); err != nil {
+		log.Printf("Error recording completion of board %s: %v", boardID.Hex(), err)
+	}
+	return nil
+}
+
+func (s *CompletionService) reopen(ctx context.Context, actorID, boardID primitive.ObjectID, reason models.CompletionReason) error {
+	return s.setCompleted(ctx, actorID, boardID, func(board *models.Board) bool {
+		if !board.Completed || !board.CompletionRules.AutoReopen {
+			return false
+		}
+		board.Completed = false
+		board.CompletionReason = reason
+		return true
+	})
+}
+
+// CompleteIfDone completes the board when its rules complete it automatically and all its tasks are done
+func (s *CompletionService) CompleteIfDone(ctx context.Context, actorID, boardID primitive.ObjectID) error {
+	board, err := s.BoardService.GetBoardById(ctx, boardID.Hex())
+	if err != nil {
+		return err
+	}
+	if board.Completed || !board.CompletionRules.AutoComplete {
+		return nil
+	}
+	total, open, err := s.TaskService.CountOpenTasks(ctx, boardID)
+	if err != nil || total == 0 || open > 0 {
+		return err
+	}
+	return s.setCompleted(ctx, actorID, boardID, func(board *models.Board) bool {
+		if board.Completed || !board.CompletionRules.AutoComplete {
+			return false
+		}
+		board.Completed = true
+		board.CompletionReason = models.CompletedAllDone
+		return true
+	})
+}
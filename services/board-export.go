@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetAllTasksOfBoard returns every task of the board for an export, in the order of the
+// workflow columns and then by rank. The board may be archived.
+func (s *TaskService) GetAllTasksOfBoard(ctx context.Context, board *models.Board) ([]models.Task, error) {
+	tasks, err := s.findAll(ctx, bson.M{"board_id": board.ID},
+		options.Find().SetSort(bson.D{{Key: "rank", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	// tasks in a status without a column go last
+	columns := map[models.TaskStatus]int{}
+	for i, status := range board.Statuses() {
+		columns[status] = i
+	}
+	column := func(status models.TaskStatus) int {
+		if i, ok := columns[status]; ok {
+			return i
+		}
+		return len(columns)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return column(tasks[i].Status) < column(tasks[j].Status)
+	})
+	return tasks, nil
+}
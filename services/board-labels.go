@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrLabelNotFound = errors.New("label not found")
+var ErrDuplicateLabel = errors.New("the board already has a label with that name")
+
+// modifyBoard applies modify to the latest stored board and saves it with the version check, retrying lost races
+func (s *BoardService) modifyBoard(ctx context.Context, id string, modify func(*models.Board) error) (*models.Board, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		board, err := s.GetBoardById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := modify(board); err != nil {
+			return nil, err
+		}
+		board.UpdatedAt = time.Now().UTC()
+
+		err = s.UpdateBoard(ctx, id, board)
+		if errors.Is(err, ErrVersionMismatch) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return board, nil
+	}
+	return nil, ErrVersionMismatch
+}
+
+func hasLabelNamed(board *models.Board, name string, except primitive.ObjectID) bool {
+	for _, label := range board.Labels {
+		if label.ID != except && strings.EqualFold(label.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BoardService) AddLabel(ctx context.Context, boardID string, label models.Label) (*models.Board, error) {
+	label.ID = primitive.NewObjectID()
+	return s.modifyBoard(ctx, boardID, func(board *models.Board) error {
+		if hasLabelNamed(board, label.Name, label.ID) {
+			return ErrDuplicateLabel
+		}
+		board.Labels = append(board.Labels, label)
+		return nil
+	})
+}
+
+// UpdateLabel renames or recolors a label. Tasks reference labels by id so they follow the change.
+func (s *BoardService) UpdateLabel(ctx context.Context, boardID string, labelID primitive.ObjectID, request models.LabelRequest) (*models.Board, error) {
+	return s.modifyBoard(ctx, boardID, func(board *models.Board) error {
+		label, ok := board.Label(labelID)
+		if !ok {
+			return ErrLabelNotFound
+		}
+		if hasLabelNamed(board, request.Name, labelID) {
+			return ErrDuplicateLabel
+		}
+		label.Name = request.Name
+		label.Color = request.Color
+		return nil
+	})
+}
+
+// RemoveLabel deletes the label from the catalog, TaskService.RemoveLabelFromTasks clears it from the tasks
+func (s *BoardService) RemoveLabel(ctx context.Context, boardID string, labelID primitive.ObjectID) (*models.Board, error) {
+	return s.modifyBoard(ctx, boardID, func(board *models.Board) error {
+		for i, label := range board.Labels {
+			if label.ID == labelID {
+				board.Labels = append(board.Labels[:i], board.Labels[i+1:]...)
+				return nil
+			}
+		}
+		return ErrLabelNotFound
+	})
+}
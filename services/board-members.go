@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrAlreadyMember = errors.New("the user is already a member of the board")
+var ErrNotMember = errors.New("the user is not a member of the board")
+
+// UnknownUserIDs returns the ids that belong to no user
+func (s *UserService) UnknownUserIDs(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	unknown := []primitive.ObjectID{}
+	if len(ids) == 0 {
+		return unknown, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := s.db.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	found := map[primitive.ObjectID]bool{}
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		found[user.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown, nil
+}
+
+// AddMember gives the user access to the board
+func (s *BoardService) AddMember(ctx context.Context, id string, userID primitive.ObjectID) (*models.Board, error) {
+	return s.modifyBoard(ctx, id, func(board *models.Board) error {
+		if board.IsMember(userID) {
+			return ErrAlreadyMember
+		}
+		board.Members = append(board.Members, userID)
+		return nil
+	})
+}
+
+// RemoveMember takes the access to the board away from the user, the owner cannot be removed
+func (s *BoardService) RemoveMember(ctx context.Context, id string, userID primitive.ObjectID) (*models.Board, error) {
+	return s.modifyBoard(ctx, id, func(board *models.Board) error {
+		members := []primitive.ObjectID{}
+		for _, member := range board.Members {
+			if member != userID {
+				members = append(members, member)
+			}
+		}
+		if len(members) == len(board.Members) {
+			return ErrNotMember
+		}
+		board.Members = members
+		return nil
+	})
+}
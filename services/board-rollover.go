@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrBoardCompleted = errors.New("the board is already completed")
+var ErrRolloverTarget = errors.New("tasks can only be rolled over to another open board")
+
+// RolloverService rolls the unfinished tasks of a finished board over to the next one
+type RolloverService struct {
+	TaskService     *TaskService
+	BoardService    *BoardService
+	CommentService  *CommentService
+	WorkLogService  *WorkLogService
+	ActivityService *ActivityService
+}
+
+func NewRolloverService(taskService *TaskService, boardService *BoardService, commentService *CommentService, workLogService *WorkLogService, activityService *ActivityService) *RolloverService {
+	return &RolloverService{
+		TaskService:     taskService,
+		BoardService:    boardService,
+		CommentService:  commentService,
+		WorkLogService:  workLogService,
+		ActivityService: activityService,
+	}
+}
+
+// RolledOverTask is the outcome of the rollover for one task
+type RolledOverTask struct {
+	TaskID primitive.ObjectID
+	Task   *models.Task
+	Err    error
+}
+
+// record stores the entry when there is one, the change already happened so a failure is only logged
+func (s *RolloverService) record(ctx context.Context, activity *models.Activity) {
+	if activity == nil {
+		return
+	}
+	if err := s.ActivityService.Record(ctx, activity); err != nil {
+		log.Printf("Error recording %s activity of board %s: %v", activity.Action, activity.BoardID.Hex(), err)
+	}
+}
+
+// NextBoard returns the board the source rolls over to: the one of an earlier rollover that did not
+// finish, or the open board of the owner right after the source, leaving archived boards out. When
+// there is none it creates it with the same members, labels, workflow and duration as the source,
+// and reports it was created.
+func (s *RolloverService) NextBoard(ctx context.Context, actorID primitive.ObjectID, source *models.Board) (*models.Board, bool, error) {
+	if source.RolledOverTo != nil {
+		next, err := s.BoardService.GetBoardById(ctx, source.RolledOverTo.Hex())
+		if err == nil && !next.Archived {
+			return next, false, nil
+		}
+	}
+
+	// The next board starts the day after the source ends, at the same time of day as the source
+	shift := source.ToDate.UTC().Truncate(24*time.Hour).Sub(source.FromDate.UTC().Truncate(24*time.Hour)) + 24*time.Hour
+	from := source.FromDate.Add(shift)
+	next, err := s.BoardService.FindBoardForDate(ctx, source.OwnerID, from, primitive.NilObjectID)
+	if err == nil {
+		return next, false, nil
+	}
+	if !errors.Is(err, ErrNoBoardForOccurrence) {
+		return nil, false, err
+	}
+
+	now := time.Now().UTC()
+	next = &models.Board{
+		ID:              primitive.NewObjectID(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Title:           source.Title,
+		FromDate:        from,
+		ToDate:          source.ToDate.Add(shift),
+		OwnerID:         source.OwnerID,
+		Version:         1,
+		Members:         source.Members,
+		Workflow:        source.Workflow,
+		AutoRollover:    source.AutoRollover,
+		CompletionRules: source.CompletionRules,
+		RolledOverFrom:  &source.ID,
+	}
+	for _, label := range source.Labels {
+		label.ID = primitive.NewObjectID()
+		next.Labels = append(next.Labels, label)
+	}
+	if err := s.BoardService.CreateBoard(ctx, next); err != nil {
+		return nil, false, err
+	}
+	s.record(ctx, BoardActivity(actorID, nil, next))
+	return next, true, nil
+}
+
+// Rollover moves or copies the TODO and DOING tasks of the source to the target and completes the
+// source. The tasks keep a link to the source, and copies to their original. When a task cannot be
+// rolled over the source stays open, so that the rollover can be run again for the tasks left.
+func (s *RolloverService) Rollover(ctx context.Context, actorID primitive.ObjectID, source, target *models.Board, mode models.RolloverMode, force bool) (*models.Board, []RolledOverTask, error) {
+	if source.Completed {
+		return nil, nil, ErrBoardCompleted
+	}
+	if source.Archived || target.Archived {
+		return nil, nil, ErrBoardArchived
+	}
+	if target.ID == source.ID || target.Completed {
+		return nil, nil, ErrRolloverTarget
+	}
+
+	tasks, err := s.TaskService.findAll(ctx, bson.M{
+		"board_id":        source.ID,
+		"status_category": bson.M{"$in": []models.StatusCategory{models.CategoryTodo, models.CategoryInProgress}},
+	}, options.Find().SetSort(bson.D{{Key: "status", Value: 1}, {Key: "rank", Value: 1}}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Copies are kept on the source, the ones already copied by an earlier run are left out
+	copied := map[primitive.ObjectID]bool{}
+	if mode == models.RolloverCopy && len(tasks) > 0 {
+		ids := []primitive.ObjectID{}
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		copies, err := s.TaskService.findAll(ctx, bson.M{"board_id": target.ID, "copied_from": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, task := range copies {
+			copied[*task.CopiedFrom] = true
+		}
+	}
+
+	results := []RolledOverTask{}
+	failed := false
+	for _, task := range tasks {
+		if copied[task.ID] {
+			continue
+		}
+		result, err := s.rolloverTask(ctx, actorID, task, source, target, mode, force)
+		results = append(results, RolledOverTask{TaskID: task.ID, Task: result, Err: err})
+		failed = failed || err != nil
+	}
+	if failed {
+		return source, results, nil
+	}
+
+	completed, err := s.BoardService.modifyBoard(ctx, source.ID.Hex(), func(board *models.Board) error {
+		if board.Completed {
+			return ErrBoardCompleted
+		}
+		board.Completed = true
+		board.CompletionReason = models.CompletedRolledOver
+		board.RolledOverTo = &target.ID
+		return nil
+	})
+	if err != nil {
+		return nil, results, err
+	}
+	s.record(ctx, BoardActivity(actorID, source, completed))
+	return completed, results, nil
+}
+
+func (s *RolloverService) rolloverTask(ctx context.Context, actorID primitive.ObjectID, task models.Task, source, target *models.Board, mode models.RolloverMode, force bool) (*models.Task, error) {
+	result := task
+	result.Checklist = append([]models.ChecklistItem(nil), task.Checklist...)
+	RetargetTask(&result, source, target)
+	column, _ := target.Column(result.Status)
+	result.StatusCategory = column.Category
+	result.RolledOverFrom = &source.ID
+	now := time.Now().UTC()
+	result.UpdatedAt = now
+
+	if mode == models.RolloverCopy {
+		PrepareCopy(&result, task.ID, now)
+		result.RolledOverFrom = &source.ID
+		err := s.TaskService.EnterColumn(ctx, target.ID, result.ID, "", *column, force, func() error {
+			return s.TaskService.CreateTask(ctx, &result)
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.record(ctx, TaskActivity(actorID, nil, &result))
+		return &result, nil
+	}
+
+	err := s.TaskService.EnterColumn(ctx, target.ID, result.ID, "", *column, force, func() error {
+		return s.TaskService.MoveTaskToBoard(ctx, &result, source.OwnerID == target.OwnerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CommentService.MoveCommentsToBoard(ctx, result.ID, target.ID); err != nil {
+		log.Printf("Error moving comments of task %s to board %s: %v", result.ID.Hex(), target.ID.Hex(), err)
+	}
+	if err := s.WorkLogService.MoveWorkLogsToBoard(ctx, result.ID, target.ID); err != nil {
+		log.Printf("Error moving work logs of task %s to board %s: %v", result.ID.Hex(), target.ID.Hex(), err)
+	}
+	s.record(ctx, TaskActivity(actorID, &task, &result))
+	return &result, nil
+}
+
+// RolloverDue rolls over the open, not archived boards with automatic rollover that ended before today,
+// acting as their owners. It returns how many boards were completed.
+// A board that is left open is not tried again before retryDelay, so it does not hold back the others.
+func (s *RolloverService) RolloverDue(ctx context.Context, now time.Time, limit int64, retryDelay time.Duration) (int, []error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	boards := []models.Board{}
+	cursor, err := s.BoardService.db.Find(ctx, bson.M{
+		"completed":     false,
+		"archived":      bson.M{"$ne": true},
+		"auto_rollover": bson.M{"$in": []models.RolloverMode{models.RolloverMove, models.RolloverCopy}},
+		"to_date":       bson.M{"$lt": today},
+		"$or": []bson.M{
+			{"next_rollover_at": bson.M{"$exists": false}},
+			{"next_rollover_at": bson.M{"$lte": now}},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "to_date", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return 0, []error{err}
+	}
+	if err := cursor.All(ctx, &boards); err != nil {
+		return 0, []error{err}
+	}
+
+	completed := 0
+	errs := []error{}
+	for i := range boards {
+		source := &boards[i]
+		board, taskErrs, err := s.rolloverDueBoard(ctx, source)
+		errs = append(errs, taskErrs...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if err == nil && board.Completed {
+			completed++
+			continue
+		}
+		if err := s.BoardService.deferRollover(ctx, source.ID, now.Add(retryDelay)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return completed, errs
+}
+
+// rolloverDueBoard rolls the board over to the next board of its owner, it also returns the errors of single tasks
+func (s *RolloverService) rolloverDueBoard(ctx context.Context, source *models.Board) (*models.Board, []error, error) {
+	target, _, err := s.NextBoard(ctx, source.OwnerID, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	board, results, err := s.Rollover(ctx, source.OwnerID, source, target, source.AutoRollover, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	taskErrs := []error{}
+	for _, result := range results {
+		if result.Err != nil {
+			taskErrs = append(taskErrs, result.Err)
+		}
+	}
+	return board, taskErrs, nil
+}
+
+// deferRollover keeps the scheduler from rolling the board over before at.
+// It is bookkeeping of the scheduler, so the version of the board is left as is.
+func (s *BoardService) deferRollover(ctx context.Context, boardID primitive.ObjectID, at time.Time) error {
+	_, err := s.db.UpdateOne(ctx,
+		bson.M{"_id": boardID},
+		bson.M{"$set": bson.M{"next_rollover_at": at}},
+	)
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type BoardService struct {
@@ -18,6 +19,19 @@ func NewBoardService(db *mongo.Collection) *BoardService {
 	return &BoardService{db: db}
 }
 
+// EnsureIndexes creates the indexes backing the stable created_at + _id ordering of board listings, title search
+// and the automatic rollover
+func (s *BoardService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "title", Value: "text"}}},
+		{Keys: bson.D{{Key: "members", Value: 1}}},
+		{Keys: bson.D{{Key: "completed", Value: 1}, {Key: "auto_rollover", Value: 1}, {Key: "to_date", Value: 1}}},
+	})
+	return err
+}
+
 func (s *BoardService) CreateBoard(ctx context.Context, board *models.Board) error {
 	_, err := s.db.InsertOne(ctx, board)
 	return err
@@ -44,62 +58,166 @@ func (s *BoardService) GetBoardById(ctx context.Context, id string) (*models.Boa
 	return &board, err
 }
 
-func (s *BoardService) GetBoards(ctx context.Context) ([]models.Board, error) {
-	var boards []models.Board
-	cursor, err := s.db.Find(ctx, bson.M{})
+// GetBoards returns one page of boards and the cursor of the next page, empty on the last one
+func (s *BoardService) GetBoards(ctx context.Context, query models.BoardQuery, page models.PageRequest) ([]models.Board, string, error) {
+	return findPage(ctx, s.db, archiveFilter(bson.M{}, query.Archived), page, boardPageKey)
+}
+
+func (s *BoardService) GetBoardsByOwnerID(ctx context.Context, ownerID string, query models.BoardQuery, page models.PageRequest) ([]models.Board, string, error) {
+	ownerObjectID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return findPage(ctx, s.db, archiveFilter(bson.M{"owner_id": ownerObjectID}, query.Archived), page, boardPageKey)
+}
+
+// GetBoardIDsByOwnerID returns only the ids of every board of the owner, for cascading operations
+func (s *BoardService) GetBoardIDsByOwnerID(ctx context.Context, ownerID string) ([]primitive.ObjectID, error) {
+	ownerObjectID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := s.db.Find(ctx, bson.M{"owner_id": ownerObjectID}, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
+	var ids []primitive.ObjectID
 	for cursor.Next(ctx) {
 		var board models.Board
 		if err := cursor.Decode(&board); err != nil {
 			return nil, err
 		}
-		boards = append(boards, board)
+		ids = append(ids, board.ID)
 	}
-	return boards, nil
+	return ids, nil
 }
 
-func (s *BoardService) GetBoardsByOwnerID(ctx context.Context, ownerID string) ([]models.Board, error) {
-	var boards []models.Board
+// UpdateBoard replaces the board only if it is still at board.Version and bumps the version on success
+func (s *BoardService) UpdateBoard(ctx context.Context, id string, board *models.Board) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid board id")
+	}
 
-	ownerObjectID, err := primitive.ObjectIDFromHex(ownerID)
+	expected := board.Version
+	board.Version = expected + 1
+	result, err := s.db.UpdateOne(ctx, versionFilter(objID, expected), bson.M{"$set": board})
+	if err != nil {
+		board.Version = expected
+		return err
+	}
+	if result.MatchedCount == 0 {
+		board.Version = expected
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// DeleteBoardAtVersion deletes the board only if it is still at the given version
+func (s *BoardService) DeleteBoardAtVersion(ctx context.Context, id string, version int64) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid board id")
+	}
+	result, err := s.db.DeleteOne(ctx, versionFilter(objID, version))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+func (s *BoardService) IsUserOwnerOfBoard(ctx context.Context, boardID string, userID string) (bool, error) {
+	board, err := s.GetBoardById(ctx, boardID)
+	if err != nil {
+		return false, err
+	}
+
+	return board.OwnerID.Hex() == userID, nil
+}
+
+// accessibleBoardsFilter matches the boards a user is allowed to read: owned or shared with them
+func accessibleBoardsFilter(userID primitive.ObjectID) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"owner_id": userID},
+		{"members": userID},
+	}}
+}
+
+// GetAccessibleBoardIDs returns the ids of every board the user is allowed to read
+func (s *BoardService) GetAccessibleBoardIDs(ctx context.Context, userID string) ([]primitive.ObjectID, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	cursor, err := s.db.Find(ctx, bson.M{"owner_id": ownerObjectID})
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := s.db.Find(ctx, accessibleBoardsFilter(userObjectID), opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
+	var ids []primitive.ObjectID
 	for cursor.Next(ctx) {
 		var board models.Board
 		if err := cursor.Decode(&board); err != nil {
 			return nil, err
 		}
-		boards = append(boards, board)
+		ids = append(ids, board.ID)
 	}
-	return boards, nil
+	return ids, nil
 }
 
-func (s *BoardService) UpdateBoard(ctx context.Context, id string, board models.Board) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+func (s *BoardService) GetBoardsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Board, error) {
+	cursor, err := s.db.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
 	if err != nil {
-		return errors.New("invalid board id")
+		return nil, err
 	}
-	_, err = s.db.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": board})
-	return err
+	defer cursor.Close(ctx)
+
+	boards := []models.Board{}
+	if err := cursor.All(ctx, &boards); err != nil {
+		return nil, err
+	}
+	return boards, nil
 }
 
-func (s *BoardService) IsUserOwnerOfBoard(ctx context.Context, boardID string, userID string) (bool, error) {
-	board, err := s.GetBoardById(ctx, boardID)
+type scoredBoard struct {
+	models.Board `bson:",inline"`
+	Score        float64 `bson:"score"`
+}
+
+// searchBoards runs a text search on the titles of the boards the user can read, best matches first
+func (s *BoardService) searchBoards(ctx context.Context, userID string, text string, limit int64) ([]scoredBoard, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return board.OwnerID.Hex() == userID, nil
+	filter := accessibleBoardsFilter(userObjectID)
+	filter["$text"] = bson.M{"$search": text}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(limit)
+
+	cursor, err := s.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	boards := []scoredBoard{}
+	if err := cursor.All(ctx, &boards); err != nil {
+		return nil, err
+	}
+	return boards, nil
 }
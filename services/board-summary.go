@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"time"
+	"todoerbk/database"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// boardWithStats is a board joined with its task counts grouped by status, category and priority
+type boardWithStats struct {
+	models.Board `bson:",inline"`
+	Stats        []struct {
+		ID struct {
+			Status   models.TaskStatus     `bson:"status"`
+			Category models.StatusCategory `bson:"status_category"`
+			Priority models.TaskPriority   `bson:"priority"`
+		} `bson:"_id"`
+		Count             int `bson:"count"`
+		ChecklistTotal    int `bson:"checklist_total"`
+		ChecklistDone     int `bson:"checklist_done"`
+		OriginalEstimate  int `bson:"original_estimate_minutes"`
+		RemainingEstimate int `bson:"remaining_estimate_minutes"`
+		Logged            int `bson:"logged_minutes"`
+	} `bson:"stats"`
+}
+
+// GetBoardSummariesByOwnerID returns one page of the owner's boards with their task counts.
+// The counts come from a $lookup in the same aggregation, so it is a single round trip.
+func (s *BoardService) GetBoardSummariesByOwnerID(ctx context.Context, ownerID string, query models.BoardQuery, page models.PageRequest) ([]models.BoardSummary, string, error) {
+	ownerObjectID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := pageLimit(page.Limit)
+
+	match := archiveFilter(bson.M{"owner_id": ownerObjectID}, query.Archived)
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		match = bson.M{"$and": []bson.M{match, afterCursor("created_at", false, c)}}
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit + 1},
+		{"$lookup": bson.M{
+			"from": database.TasksCollection,
+			"let":  bson.M{"boardId": "$_id"},
+			"pipeline": []bson.M{
+				{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$board_id", "$$boardId"}}}},
+				{"$group": bson.M{
+					"_id":                        bson.M{"status": "$status", "status_category": "$status_category", "priority": "$priority"},
+					"count":                      bson.M{"$sum": 1},
+					"checklist_total":            bson.M{"$sum": "$checklist_progress.total"},
+					"checklist_done":             bson.M{"$sum": "$checklist_progress.done"},
+					"original_estimate_minutes":  bson.M{"$sum": "$original_estimate_minutes"},
+					"remaining_estimate_minutes": bson.M{"$sum": "$remaining_estimate_minutes"},
+					"logged_minutes":             bson.M{"$sum": "$logged_minutes"},
+				}},
+			},
+			"as": "stats",
+		}},
+	}
+
+	cursor, err := s.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	rows := []boardWithStats{}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	summaries := make([]models.BoardSummary, 0, len(rows))
+	for _, row := range rows {
+		summaries = append(summaries, summarizeBoard(row, now))
+	}
+
+	return trimPage(summaries, limit, func(summary models.BoardSummary) (interface{}, primitive.ObjectID) {
+		return boardPageKey(summary.Board)
+	})
+}
+
+func summarizeBoard(row boardWithStats, now time.Time) models.BoardSummary {
+	summary := models.BoardSummary{
+		Board:        row.Board,
+		StatusCounts: map[models.TaskStatus]int{},
+		CategoryCounts: map[models.StatusCategory]int{
+			models.CategoryTodo: 0, models.CategoryInProgress: 0, models.CategoryDone: 0,
+		},
+		PriorityCounts: map[models.TaskPriority]int{
+			models.LOW: 0, models.MEDIUM: 0, models.HIGH: 0,
+		},
+	}
+
+	for _, status := range row.Statuses() {
+		summary.StatusCounts[status] = 0
+	}
+
+	for _, stat := range row.Stats {
+		summary.TotalTasks += stat.Count
+		summary.StatusCounts[stat.ID.Status] += stat.Count
+		summary.CategoryCounts[stat.ID.Category] += stat.Count
+		summary.PriorityCounts[stat.ID.Priority] += stat.Count
+		summary.Checklist.Total += stat.ChecklistTotal
+		summary.Checklist.Done += stat.ChecklistDone
+		summary.TimeTotals.OriginalEstimate += stat.OriginalEstimate
+		summary.TimeTotals.RemainingEstimate += stat.RemainingEstimate
+		summary.TimeTotals.Logged += stat.Logged
+	}
+
+	summary.ColumnLoads = ColumnLoads(row.Board, summary.StatusCounts)
+
+	done := summary.CategoryCounts[models.CategoryDone]
+	if summary.TotalTasks > 0 {
+		summary.CompletionRatio = float64(done) / float64(summary.TotalTasks)
+	}
+	summary.Overdue = !row.Completed && row.ToDate.Before(now) && done < summary.TotalTasks
+
+	return summary
+}
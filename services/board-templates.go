@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TemplateService keeps the board templates and creates boards from templates and from other boards
+type TemplateService struct {
+	db           *mongo.Collection
+	BoardService *BoardService
+	TaskService  *TaskService
+}
+
+func NewTemplateService(db *mongo.Collection, boardService *BoardService, taskService *TaskService) *TemplateService {
+	return &TemplateService{db: db, BoardService: boardService, TaskService: taskService}
+}
+
+// EnsureIndexes creates the index backing the template listing of a user
+func (s *TemplateService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
+
+func (s *TemplateService) CreateTemplate(ctx context.Context, template *models.BoardTemplate) error {
+	_, err := s.db.InsertOne(ctx, template)
+	return err
+}
+
+func (s *TemplateService) GetTemplateById(ctx context.Context, id string) (*models.BoardTemplate, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid template id")
+	}
+	var template models.BoardTemplate
+	err = s.db.FindOne(ctx, bson.M{"_id": objID}).Decode(&template)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetTemplatesByOwnerId returns one page of the templates of the user, oldest first
+func (s *TemplateService) GetTemplatesByOwnerId(ctx context.Context, ownerID primitive.ObjectID, page models.PageRequest) ([]models.BoardTemplate, string, error) {
+	return findPage(ctx, s.db, bson.M{"owner_id": ownerID}, page, func(template models.BoardTemplate) (interface{}, primitive.ObjectID) {
+		return template.CreatedAt, template.ID
+	})
+}
+
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.db.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (s *TemplateService) DeleteTemplatesByOwnerId(ctx context.Context, ownerID string) error {
+	objID, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+	_, err = s.db.DeleteMany(ctx, bson.M{"owner_id": objID})
+	return err
+}
+
+// boardDays is the number of days of the board, both ends included
+func boardDays(board *models.Board) int {
+	return int(board.ToDate.UTC().Truncate(24*time.Hour).Sub(board.FromDate.UTC().Truncate(24*time.Hour))/(24*time.Hour)) + 1
+}
+
+// boardTasks returns the tasks of the board column by column, in their order
+func (s *TemplateService) boardTasks(ctx context.Context, boardID primitive.ObjectID) ([]models.Task, error) {
+	return s.TaskService.findAll(ctx, bson.M{"board_id": boardID}, options.Find().SetSort(bson.D{{Key: "status", Value: 1}, {Key: "rank", Value: 1}}))
+}
+
+// TemplateFromBoard builds a template of the board for the user. The board tasks picked by the
+// request become starter tasks, their due dates kept relative to the start of the board.
+func (s *TemplateService) TemplateFromBoard(ctx context.Context, board *models.Board, ownerID primitive.ObjectID, request models.BoardTemplateRequest) (*models.BoardTemplate, error) {
+	template := &models.BoardTemplate{
+		ID:              primitive.NewObjectID(),
+		CreatedAt:       time.Now().UTC(),
+		OwnerID:         ownerID,
+		Name:            request.Name,
+		TitlePattern:    request.TitlePattern,
+		DurationDays:    request.DurationDays,
+		Workflow:        board.Workflow,
+		Labels:          board.Labels,
+		Tasks:           []models.TemplateTask{},
+		CompletionRules: board.CompletionRules,
+	}
+	if template.TitlePattern == "" {
+		template.TitlePattern = board.Title
+	}
+	if template.DurationDays == 0 {
+		template.DurationDays = boardDays(board)
+	}
+	if !request.IncludeTasks && len(request.TaskIDs) == 0 {
+		return template, nil
+	}
+
+	tasks, err := s.boardTasks(ctx, board.ID)
+	if err != nil {
+		return nil, err
+	}
+	picked := map[primitive.ObjectID]bool{}
+	for _, id := range request.TaskIDs {
+		picked[id] = true
+	}
+	start := board.FromDate.UTC().Truncate(24 * time.Hour)
+	for _, task := range tasks {
+		if !request.IncludeTasks && !picked[task.ID] {
+			continue
+		}
+		starter := models.TemplateTask{
+			Title:            task.Title,
+			Priority:         task.Priority,
+			LabelIDs:         task.LabelIDs,
+			StoryPoints:      task.StoryPoints,
+			OriginalEstimate: task.OriginalEstimate,
+		}
+		for _, item := range task.Checklist {
+			starter.Checklist = append(starter.Checklist, item.Text)
+		}
+		if task.DueDate != nil {
+			days := int(task.DueDate.UTC().Truncate(24*time.Hour).Sub(start) / (24 * time.Hour))
+			starter.DueInDays = &days
+		}
+		template.Tasks = append(template.Tasks, starter)
+	}
+	return template, nil
+}
+
+// ExpandTitle replaces {from}, {to} and {week} in the pattern with the dates of the board
+func ExpandTitle(pattern string, from, to time.Time) string {
+	_, week := from.ISOWeek()
+	return strings.NewReplacer(
+		"{from}", from.Format("2006-01-02"),
+		"{to}", to.Format("2006-01-02"),
+		"{week}", strconv.Itoa(week),
+	).Replace(pattern)
+}
+
+// newBoardLabels gives the labels new ids for a new board, it returns the new id of every old one
+func newBoardLabels(labels []models.Label) ([]models.Label, map[primitive.ObjectID]primitive.ObjectID) {
+	ids := map[primitive.ObjectID]primitive.ObjectID{}
+	copies := []models.Label{}
+	for _, label := range labels {
+		ids[label.ID] = primitive.NewObjectID()
+		label.ID = ids[label.ID]
+		copies = append(copies, label)
+	}
+	return copies, ids
+}
+
+// createBoardWithTasks stores the new board and then its tasks in order
+func (s *TemplateService) createBoardWithTasks(ctx context.Context, board *models.Board, tasks []models.Task) error {
+	if err := s.BoardService.CreateBoard(ctx, board); err != nil {
+		return err
+	}
+	for i := range tasks {
+		if err := s.TaskService.CreateTask(ctx, &tasks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateBoardFromTemplate creates a board of the user from the template, starting on the date of the request
+func (s *TemplateService) CreateBoardFromTemplate(ctx context.Context, template *models.BoardTemplate, ownerID primitive.ObjectID, request models.BoardFromTemplateRequest) (*models.Board, []models.Task, error) {
+	now := time.Now().UTC()
+	from := request.FromDate.UTC()
+	to := from.AddDate(0, 0, template.DurationDays-1)
+	board := &models.Board{
+		ID:              primitive.NewObjectID(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Title:           request.Title,
+		FromDate:        from,
+		ToDate:          to,
+		OwnerID:         ownerID,
+		Version:         1,
+		Workflow:        template.Workflow,
+		CompletionRules: template.CompletionRules,
+	}
+	if board.Title == "" {
+		board.Title = ExpandTitle(template.TitlePattern, from, to)
+	}
+	labels, labelIDs := newBoardLabels(template.Labels)
+	board.Labels = labels
+
+	column, _ := board.Column(board.InitialStatus())
+	tasks := []models.Task{}
+	for _, starter := range template.Tasks {
+		task := models.Task{
+			ID:                primitive.NewObjectID(),
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			Title:             starter.Title,
+			Status:            column.Status,
+			StatusCategory:    column.Category,
+			Priority:          starter.Priority,
+			BoardID:           board.ID,
+			Version:           1,
+			StoryPoints:       starter.StoryPoints,
+			OriginalEstimate:  starter.OriginalEstimate,
+			RemainingEstimate: starter.OriginalEstimate,
+		}
+		for _, labelID := range starter.LabelIDs {
+			if id, ok := labelIDs[labelID]; ok {
+				task.LabelIDs = append(task.LabelIDs, id)
+			}
+		}
+		for _, text := range starter.Checklist {
+			task.Checklist = append(task.Checklist, models.ChecklistItem{ID: primitive.NewObjectID(), Text: text})
+		}
+		if starter.DueInDays != nil {
+			due := from.AddDate(0, 0, *starter.DueInDays)
+			task.DueDate = &due
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := s.createBoardWithTasks(ctx, board, tasks); err != nil {
+		return nil, nil, err
+	}
+	return board, tasks, nil
+}
+
+// CloneBoard copies the board for the user, who owns the copy while the owner and members of the
+// source become its members. Copied tasks link back to their originals.
+func (s *TemplateService) CloneBoard(ctx context.Context, source *models.Board, ownerID primitive.ObjectID, request models.BoardCloneRequest) (*models.Board, []models.Task, error) {
+	now := time.Now().UTC()
+	shift := time.Duration(0)
+	if request.FromDate != nil {
+		shift = request.FromDate.Sub(source.FromDate)
+	}
+	board := &models.Board{
+		ID:              primitive.NewObjectID(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Title:           request.Title,
+		FromDate:        source.FromDate.Add(shift),
+		ToDate:          source.ToDate.Add(shift),
+		OwnerID:         ownerID,
+		Version:         1,
+		Workflow:        source.Workflow,
+		CompletionRules: source.CompletionRules,
+	}
+	if board.Title == "" {
+		board.Title = source.Title + " (copy)"
+	}
+	for _, member := range append([]primitive.ObjectID{source.OwnerID}, source.Members...) {
+		if !board.IsMember(member) {
+			board.Members = append(board.Members, member)
+		}
+	}
+	board.Labels, _ = newBoardLabels(source.Labels)
+
+	tasks := []models.Task{}
+	if request.IncludeTasks {
+		originals, err := s.boardTasks(ctx, source.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, original := range originals {
+			task := original
+			task.Checklist = append([]models.ChecklistItem(nil), original.Checklist...)
+			RetargetTask(&task, source, board)
+			PrepareCopy(&task, original.ID, now)
+			if task.DueDate != nil {
+				due := task.DueDate.Add(shift)
+				task.DueDate = &due
+				for i := range task.Reminders {
+					task.Reminders[i].RemindAt = task.Reminders[i].RemindAt.Add(shift)
+				}
+			}
+			if request.ResetStatus {
+				task.Status = board.InitialStatus()
+				for i := range task.Checklist {
+					task.Checklist[i].Done = false
+				}
+			}
+			column, _ := board.Column(task.Status)
+			task.StatusCategory = column.Category
+			tasks = append(tasks, task)
+		}
+	}
+
+	if err := s.createBoardWithTasks(ctx, board, tasks); err != nil {
+		return nil, nil, err
+	}
+	return board, tasks, nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.-]+)`)
+
+type CommentService struct {
+	db          *mongo.Collection
+	UserService *UserService
+}
+
+func NewCommentService(db *mongo.Collection, userService *UserService) *CommentService {
+	return &CommentService{db: db, UserService: userService}
+}
+
+func (s *CommentService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "board_id", Value: 1}}},
+	})
+	return err
+}
+
+// resolveMentions returns the members of the board mentioned as @username in the body. Unknown usernames
+// and users outside the board are ignored alike, so mentions neither reach them nor tell who exists.
+func (s *CommentService) resolveMentions(ctx context.Context, board *models.Board, body string) []primitive.ObjectID {
+	seen := map[string]bool{}
+	mentions := []primitive.ObjectID{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+
+		user, err := s.UserService.GetUserByUsername(ctx, username)
+		if err != nil || !board.IsMember(user.ID) {
+			continue
+		}
+		mentions = append(mentions, user.ID)
+	}
+	return mentions
+}
+
+// CreateComment stores the comment of a task of the board with the members it mentions
+func (s *CommentService) CreateComment(ctx context.Context, board *models.Board, comment *models.Comment) error {
+	comment.Mentions = s.resolveMentions(ctx, board, comment.Body)
+	_, err := s.db.InsertOne(ctx, comment)
+	return err
+}
+
+func (s *CommentService) GetCommentById(ctx context.Context, id string) (*models.Comment, error) {
+	var comment models.Comment
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid comment id")
+	}
+
+	err = s.db.FindOne(ctx, bson.M{"_id": objID}).Decode(&comment)
+	return &comment, err
+}
+
+// GetCommentsByTaskId returns one page of the discussion of a task, oldest first
+func (s *CommentService) GetCommentsByTaskId(ctx context.Context, taskID string, page models.PageRequest) ([]models.Comment, string, error) {
+	objID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, "", errors.New("invalid task id")
+	}
+
+	return findPage(ctx, s.db, bson.M{"task_id": objID}, page, func(c models.Comment) (interface{}, primitive.ObjectID) {
+		return c.CreatedAt, c.ID
+	})
+}
+
+// UpdateCommentBody replaces the body, marks the comment as edited and resolves the mentions again
+func (s *CommentService) UpdateCommentBody(ctx context.Context, board *models.Board, comment *models.Comment, body string) error {
+	comment.Body = body
+	comment.Edited = true
+	comment.UpdatedAt = time.Now().UTC()
+	comment.Mentions = s.resolveMentions(ctx, board, body)
+
+	_, err := s.db.UpdateOne(ctx, bson.M{"_id": comment.ID}, bson.M{"$set": bson.M{
+		"body":       comment.Body,
+		"edited":     comment.Edited,
+		"updated_at": comment.UpdatedAt,
+		"mentions":   comment.Mentions,
+	}})
+	return err
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid comment id")
+	}
+	_, err = s.db.DeleteOne(ctx, bson.M{"_id": objID})
+	return err
+}
+
+func (s *CommentService) DeleteCommentsByTaskId(ctx context.Context, taskID string) error {
+	objID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return errors.New("invalid task id")
+	}
+	_, err = s.db.DeleteMany(ctx, bson.M{"task_id": objID})
+	return err
+}
+
+// MoveCommentsToBoard follows a task that changed boards, so board deletes still find its comments
+func (s *CommentService) MoveCommentsToBoard(ctx context.Context, taskID, boardID primitive.ObjectID) error {
+	_, err := s.db.UpdateMany(ctx, bson.M{"task_id": taskID}, bson.M{"$set": bson.M{"board_id": boardID}})
+	return err
+}
+
+func (s *CommentService) DeleteCommentsByBoardId(ctx context.Context, boardID string) error {
+	objID, err := primitive.ObjectIDFromHex(boardID)
+	if err != nil {
+		return errors.New("invalid board id")
+	}
+	_, err = s.db.DeleteMany(ctx, bson.M{"board_id": objID})
+	return err
+}
+
+func (s *CommentService) DeleteCommentsByTaskIds(ctx context.Context, taskIDs []primitive.ObjectID) error {
+	_, err := s.db.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	return err
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxModifyAttempts bounds the read-modify-write retries of partial updates that lose a version race
+const maxModifyAttempts = 3
+
+// ErrVersionMismatch is returned when a document changed since the caller read it
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// versionFilter matches a document only while it is still at the expected version.
+// Documents stored before versioning existed have no version field and count as version 0.
+func versionFilter(id primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{
+			"_id": id,
+			"$or": []bson.M{
+				{"version": 0},
+				{"version": bson.M{"$exists": false}},
+			},
+		}
+	}
+	return bson.M{"_id": id, "version": version}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Mailer sends HTML emails through the SMTP server configured in the environment
+type Mailer struct {
+	smtpHost     string
+	smtpPort     string
+	smtpUsername string
+	smtpPassword string
+	fromEmail    string
+}
+
+func NewMailer() *Mailer {
+	smtpHost := os.Getenv("SMTP_HOST")
+	if smtpHost == "" {
+		smtpHost = "smtp.gmail.com"
+	}
+
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+
+	smtpUsername := os.Getenv("SMTP_USERNAME")
+	smtpPassword := os.Getenv("SMTP_PASSWORD")
+	fromEmail := os.Getenv("FROM_EMAIL")
+
+	if smtpUsername == "" || smtpPassword == "" {
+		log.Println("WARNING: SMTP credentials not set, email features will be disabled")
+	}
+
+	return &Mailer{
+		smtpHost:     smtpHost,
+		smtpPort:     smtpPort,
+		smtpUsername: smtpUsername,
+		smtpPassword: smtpPassword,
+		fromEmail:    fromEmail,
+	}
+}
+
+func (m *Mailer) Enabled() bool {
+	return m.smtpUsername != "" && m.smtpPassword != ""
+}
+
+// headerValue keeps a value on its header line, line breaks would start new headers or the body
+func headerValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, value)
+}
+
+// Send emails the HTML body. The subject can hold text written by users, so it is
+// encoded as a MIME word and neither it nor the address can break the headers.
+func (m *Mailer) Send(toEmail, subject, htmlBody string) error {
+	toEmail = headerValue(toEmail)
+	subject = headerValue(subject)
+	if !m.Enabled() {
+		log.Printf("Email sending disabled. Email for %s: %s", toEmail, subject)
+		return nil
+	}
+
+	auth := smtp.PlainAuth("", m.smtpUsername, m.smtpPassword, m.smtpHost)
+
+	// Definir los headers y el contenido separadamente
+	headers := []string{
+		"From: KNBNN application",
+		"To: " + toEmail,
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/html; charset=UTF-8",
+		"", // Línea en blanco necesaria entre headers y contenido
+	}
+
+	message := strings.Join(headers, "\r\n") + "\r\n" + htmlBody
+
+	err := smtp.SendMail(
+		m.smtpHost+":"+m.smtpPort,
+		auth,
+		m.fromEmail,
+		[]string{toEmail},
+		[]byte(message),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+
+	return nil
+}
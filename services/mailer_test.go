@@ -0,0 +1,19 @@
+package services
+
+import (
+	"mime"
+	"strings"
+	"testing"
+)
+
+func TestHeaderValueRemovesLineBreaks(t *testing.T) {
+	subject := headerValue("KNBNN app: due soon\r\nBcc: victim@example.com\n\nbody")
+	if strings.ContainsAny(subject, "\r\n") {
+		t.Fatalf("headerValue kept a line break: %q", subject)
+	}
+	encoded := mime.QEncoding.Encode("utf-8", subject)
+	decoded, err := new(mime.WordDecoder).DecodeHeader(encoded)
+	if err != nil || decoded != subject {
+		t.Errorf("encoded subject %q decodes to %q, %v, want %q", encoded, decoded, err, subject)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type NotificationService struct {
+	db          *mongo.Collection
+	UserService *UserService
+	Mailer      *Mailer
+}
+
+func NewNotificationService(db *mongo.Collection, userService *UserService, mailer *Mailer) *NotificationService {
+	return &NotificationService{db: db, UserService: userService, Mailer: mailer}
+}
+
+func (s *NotificationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
+
+// Notify stores the notification for the user and emails it.
+// Email failures are only logged, the in-app notification is what matters.
+func (s *NotificationService) Notify(ctx context.Context, notification *models.Notification) error {
+	notification.ID = primitive.NewObjectID()
+	notification.CreatedAt = time.Now().UTC()
+	notification.Read = false
+
+	if _, err := s.db.InsertOne(ctx, notification); err != nil {
+		return err
+	}
+
+	user, err := s.UserService.GetUserByID(ctx, notification.UserID.Hex())
+	if err != nil {
+		log.Printf("Unable to find user %s to email notification: %v", notification.UserID.Hex(), err)
+		return nil
+	}
+
+	body := fmt.Sprintf(`
+<html>
+<body>
+    <h2>KNBNN</h2>
+    <p>%s</p>
+</body>
+</html>`, html.EscapeString(notification.Message))
+
+	if err := s.Mailer.Send(user.Email, "KNBNN app: "+notification.Message, body); err != nil {
+		log.Printf("Error sending notification email to %s: %v", user.Email, err)
+	}
+	return nil
+}
+
+func (s *NotificationService) GetNotificationsByUserID(ctx context.Context, userID string, page models.PageRequest) ([]models.Notification, string, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return findPage(ctx, s.db, bson.M{"user_id": userObjectID}, page, func(n models.Notification) (interface{}, primitive.ObjectID) {
+		return n.CreatedAt, n.ID
+	})
+}
+
+func (s *NotificationService) MarkAsRead(ctx context.Context, id string, userID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid notification id")
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.UpdateOne(ctx, bson.M{"_id": objID, "user_id": userObjectID}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
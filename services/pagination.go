@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultPageSize int64 = 50
+	MaxPageSize     int64 = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position of the last item of a page: its sort value plus _id as tie-breaker
+type pageCursor struct {
+	Value bson.RawValue      `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+func encodeCursor(value interface{}, id primitive.ObjectID) (string, error) {
+	raw, err := bson.Marshal(bson.M{"v": value, "id": id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(cursor string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := bson.Unmarshal(raw, &c); err != nil || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ValidateCursor checks that a cursor received from a client is well formed. Cursors are not signed,
+// so a client can craft one, but it only moves the start of the page within the filter of the listing.
+func ValidateCursor(cursor string) error {
+	_, err := decodeCursor(cursor)
+	return err
+}
+
+// pageLimit applies the default page size and the server-side maximum
+func pageLimit(limit int64) int64 {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
+
+// afterCursor matches the items that come after the cursor when sorting by field and then _id
+func afterCursor(field string, desc bool, c *pageCursor) bson.M {
+	op := "$gt"
+	if desc {
+		op = "$lt"
+	}
+	return bson.M{
+		"$or": []bson.M{
+			{field: bson.M{op: c.Value}},
+			{field: c.Value, "_id": bson.M{op: c.ID}},
+		},
+	}
+}
+
+// findPage reads one page of documents ordered by created_at and _id.
+// It asks for one extra document to know whether there is a next page.
+func findPage[T any](ctx context.Context, db *mongo.Collection, filter bson.M, page models.PageRequest, key func(T) (interface{}, primitive.ObjectID)) ([]T, string, error) {
+	limit := pageLimit(page.Limit)
+
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		filter = bson.M{"$and": []bson.M{filter, afterCursor("created_at", false, c)}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(limit + 1)
+
+	cursor, err := db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	items := []T{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, "", err
+	}
+
+	return trimPage(items, limit, key)
+}
+
+// trimPage drops the look-ahead item and builds the cursor for the next page if there is one
+func trimPage[T any](items []T, limit int64, key func(T) (interface{}, primitive.ObjectID)) ([]T, string, error) {
+	if int64(len(items)) <= limit {
+		return items, "", nil
+	}
+	items = items[:limit]
+	value, id := key(items[limit-1])
+	next, err := encodeCursor(value, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return items, next, nil
+}
+
+func boardPageKey(board models.Board) (interface{}, primitive.ObjectID) {
+	return board.CreatedAt, board.ID
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const recurrenceBatchSize = 100
+
+// recurrenceRetryDelay is how long a task whose occurrence could not be generated waits before the next try
+const recurrenceRetryDelay = 15 * time.Minute
+
+// RecurrenceScheduler periodically generates the next occurrence of recurring tasks that
+// came due, and of completed ones whose completion did not generate it
+type RecurrenceScheduler struct {
+	RecurrenceService *RecurrenceService
+	interval          time.Duration
+}
+
+func NewRecurrenceScheduler(recurrenceService *RecurrenceService, interval time.Duration) *RecurrenceScheduler {
+	return &RecurrenceScheduler{RecurrenceService: recurrenceService, interval: interval}
+}
+
+// Run blocks until ctx is cancelled, generating due occurrences every interval
+func (s *RecurrenceScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, errs := s.RecurrenceService.GenerateDue(ctx, time.Now().UTC(), recurrenceBatchSize, recurrenceRetryDelay)
+			for _, err := range errs {
+				// Occurrences without a board yet are retried after recurrenceRetryDelay
+				if !errors.Is(err, ErrNoBoardForOccurrence) {
+					log.Printf("Error generating task occurrence: %v", err)
+				}
+			}
+		}
+	}
+}
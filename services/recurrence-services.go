@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrNotRecurring = errors.New("the task is not part of a recurring series")
+var ErrRecurrenceNeedsDueDate = errors.New("a recurring task needs a due date")
+var ErrNoBoardForOccurrence = errors.New("no open board of the owner covers the date of the next occurrence")
+
+// RecurrenceService generates the occurrences of recurring tasks
+type RecurrenceService struct {
+	TaskService  *TaskService
+	BoardService *BoardService
+}
+
+func NewRecurrenceService(taskService *TaskService, boardService *BoardService) *RecurrenceService {
+	return &RecurrenceService{TaskService: taskService, BoardService: boardService}
+}
+
+// StartSeries makes the task the first occurrence of a series with the rule
+func StartSeries(task *models.Task, rule string) error {
+	if task.DueDate == nil {
+		return ErrRecurrenceNeedsDueDate
+	}
+	if _, err := ParseRecurrenceRule(rule); err != nil {
+		return err
+	}
+	task.Recurrence = &models.Recurrence{
+		Rule:       rule,
+		SeriesID:   task.ID,
+		Start:      *task.DueDate,
+		Occurrence: 1,
+	}
+	return nil
+}
+
+// FindBoardForDate returns the open, not archived board of the owner whose window contains the date,
+// preferring the given board when it does
+func (s *BoardService) FindBoardForDate(ctx context.Context, ownerID primitive.ObjectID, date time.Time, preferred primitive.ObjectID) (*models.Board, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	filter := bson.M{
+		"owner_id":  ownerID,
+		"completed": false,
+		"archived":  bson.M{"$ne": true},
+		"from_date": bson.M{"$lte": date},
+		"to_date":   bson.M{"$gte": day},
+	}
+
+	var board models.Board
+	err := s.db.FindOne(ctx, bson.M{"$and": []bson.M{filter, {"_id": preferred}}}).Decode(&board)
+	if err == nil {
+		return &board, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "from_date", Value: -1}, {Key: "_id", Value: 1}})
+	err = s.db.FindOne(ctx, filter, opts).Decode(&board)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNoBoardForOccurrence
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &board, nil
+}
+
+// nextDate returns the due date of the occurrence after the one due at due, ok is false when the series is over
+func nextDate(recurrence *models.Recurrence, due time.Time) (time.Time, bool, error) {
+	rule, err := ParseRecurrenceRule(recurrence.Rule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	date, ok := rule.Next(recurrence.Start, due, recurrence.Occurrence)
+	return date, ok, nil
+}
+
+// GenerateNext creates the occurrence that follows the task, at most once per task. It returns
+// nil without error when the series is over, stopped or the next occurrence already exists;
+// a series that is over is marked as ended on the task.
+// The task must be the stored one, its version and next id are updated on success.
+func (s *RecurrenceService) GenerateNext(ctx context.Context, task *models.Task) (*models.Task, error) {
+	recurrence := task.Recurrence
+	if recurrence == nil || recurrence.Stopped || recurrence.Ended || recurrence.NextID != nil || task.DueDate == nil {
+		return nil, nil
+	}
+	date, ok, err := nextDate(recurrence, *task.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.TaskService.endSeries(ctx, task)
+	}
+
+	current, err := s.BoardService.GetBoardById(ctx, task.BoardID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	board, err := s.BoardService.FindBoardForDate(ctx, current.OwnerID, date, current.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	next := nextOccurrence(task, board, date)
+	claimed, err := s.TaskService.claimNextOccurrence(ctx, task.ID, next.ID)
+	if err != nil || !claimed {
+		return nil, err
+	}
+
+	// Generated occurrences are not held back by WIP limits, the series must go on
+	if err := s.TaskService.CreateTask(ctx, next); err != nil {
+		s.TaskService.releaseNextOccurrence(ctx, task.ID, next.ID)
+		return nil, err
+	}
+	// Keep the caller's copy in line with the claim, which bumped the stored version
+	task.Recurrence.NextID = &next.ID
+	task.Version++
+	return next, nil
+}
+
+// nextOccurrence copies the task into a new occurrence of the series due at date on the board.
+// Board scoped fields only survive when the board does not change.
+func nextOccurrence(task *models.Task, board *models.Board, date time.Time) *models.Task {
+	now := time.Now().UTC()
+	column := models.WorkflowColumn{Status: board.InitialStatus(), Category: models.CategoryTodo}
+	if initial, ok := board.Column(column.Status); ok {
+		column = *initial
+	}
+	next := &models.Task{
+		ID:             primitive.NewObjectID(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Title:          task.Title,
+		Status:         column.Status,
+		StatusCategory: column.Category,
+		Priority:       task.Priority,
+		StoryPoints:    task.StoryPoints,
+		BoardID:        board.ID,
+		Version:        1,
+		DueDate:        &date,
+		// Each occurrence starts with the full estimate and no time logged
+		OriginalEstimate:  task.OriginalEstimate,
+		RemainingEstimate: task.OriginalEstimate,
+	}
+	if board.ID == task.BoardID {
+		next.LabelIDs = task.LabelIDs
+	}
+	for _, assignee := range task.Assignees {
+		if board.IsMember(assignee) {
+			next.Assignees = append(next.Assignees, assignee)
+		}
+	}
+	for _, item := range task.Checklist {
+		item.ID = primitive.NewObjectID()
+		item.Done = false
+		if item.AssigneeID != nil && !board.IsMember(*item.AssigneeID) {
+			item.AssigneeID = nil
+		}
+		next.Checklist = append(next.Checklist, item)
+	}
+	for _, reminder := range task.Reminders {
+		next.Reminders = append(next.Reminders, models.Reminder{
+			OffsetMinutes: reminder.OffsetMinutes,
+			RemindAt:      date.Add(-time.Duration(reminder.OffsetMinutes) * time.Minute),
+		})
+	}
+
+	recurrence := *task.Recurrence
+	recurrence.Occurrence++
+	recurrence.NextID = nil
+	recurrence.NextGenerationAt = nil
+	next.Recurrence = &recurrence
+	return next
+}
+
+// GenerateDue generates the next occurrence of the recurring tasks that reached their due date
+// or were completed, the oldest first, it returns how many occurrences were created.
+// A task that yields no occurrence is not tried again before retryDelay, so it does not hold back the others.
+func (s *RecurrenceService) GenerateDue(ctx context.Context, now time.Time, limit int64, retryDelay time.Duration) (int, []error) {
+	tasks, err := s.TaskService.findAll(ctx, bson.M{
+		"recurrence":         bson.M{"$exists": true},
+		"recurrence.stopped": false,
+		"recurrence.next_id": bson.M{"$exists": false},
+		"recurrence.ended":   bson.M{"$ne": true},
+		"$and": []bson.M{
+			{"$or": []bson.M{
+				{"due_date": bson.M{"$lte": now}},
+				{"status_category": models.CategoryDone},
+			}},
+			{"$or": []bson.M{
+				{"recurrence.next_generation_at": bson.M{"$exists": false}},
+				{"recurrence.next_generation_at": bson.M{"$lte": now}},
+			}},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return 0, []error{err}
+	}
+
+	created := 0
+	errs := []error{}
+	for i := range tasks {
+		next, err := s.GenerateNext(ctx, &tasks[i])
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if next != nil {
+			created++
+			continue
+		}
+		// Without a due date, or after losing the claim, the task would come back on every run
+		if err := s.TaskService.deferOccurrence(ctx, tasks[i].ID, now.Add(retryDelay)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return created, errs
+}
+
+// UpdateSeries changes the rule of the series from the open occurrence on,
+// or starts a series when the task is not recurring yet
+func (s *RecurrenceService) UpdateSeries(ctx context.Context, taskID string, rule string) (*models.Task, error) {
+	if _, err := ParseRecurrenceRule(rule); err != nil {
+		return nil, err
+	}
+	task, err := s.TaskService.modifyTask(ctx, taskID, func(task *models.Task) error {
+		if task.Recurrence == nil {
+			return StartSeries(task, rule)
+		}
+		task.Recurrence.Rule = rule
+		task.Recurrence.Stopped = false
+		task.Recurrence.Ended = false
+		task.Recurrence.NextGenerationAt = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.TaskService.updateOpenOccurrences(ctx, task.Recurrence.SeriesID, bson.M{"recurrence.rule": rule, "recurrence.stopped": false, "recurrence.ended": false})
+	return task, err
+}
+
+// StopSeries stops generating occurrences, the existing ones are kept
+func (s *RecurrenceService) StopSeries(ctx context.Context, taskID string) (*models.Task, error) {
+	task, err := s.TaskService.modifyTask(ctx, taskID, func(task *models.Task) error {
+		if task.Recurrence == nil {
+			return ErrNotRecurring
+		}
+		task.Recurrence.Stopped = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.TaskService.updateOpenOccurrences(ctx, task.Recurrence.SeriesID, bson.M{"recurrence.stopped": true})
+	return task, err
+}
+
+// SkipOccurrence generates the occurrence after the task if needed and returns it, the caller deletes the skipped task
+func (s *RecurrenceService) SkipOccurrence(ctx context.Context, task *models.Task) (*models.Task, error) {
+	if task.Recurrence == nil {
+		return nil, ErrNotRecurring
+	}
+	if task.Recurrence.NextID != nil {
+		return s.TaskService.GetTaskById(ctx, task.Recurrence.NextID.Hex())
+	}
+	return s.GenerateNext(ctx, task)
+}
+
+// deferOccurrence keeps the scheduler from generating the next occurrence of the task before at.
+// It is bookkeeping of the scheduler, so the version of the task is left as is.
+func (s *TaskService) deferOccurrence(ctx context.Context, taskID primitive.ObjectID, at time.Time) error {
+	_, err := s.db.UpdateOne(ctx,
+		bson.M{"_id": taskID},
+		bson.M{"$set": bson.M{"recurrence.next_generation_at": at}},
+	)
+	return err
+}
+
+// endSeries marks the task as the last occurrence of its series, the caller's copy follows the stored one
+func (s *TaskService) endSeries(ctx context.Context, task *models.Task) error {
+	result, err := s.db.UpdateOne(ctx,
+		bson.M{"_id": task.ID, "recurrence.next_id": bson.M{"$exists": false}, "recurrence.ended": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"recurrence.ended": true}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 1 {
+		task.Recurrence.Ended = true
+		task.Version++
+	}
+	return nil
+}
+
+// claimNextOccurrence records the id of the next occurrence on the task unless another request did it first
+func (s *TaskService) claimNextOccurrence(ctx context.Context, taskID, nextID primitive.ObjectID) (bool, error) {
+	result, err := s.db.UpdateOne(ctx,
+		bson.M{"_id": taskID, "recurrence.stopped": false, "recurrence.next_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"recurrence.next_id": nextID}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (s *TaskService) releaseNextOccurrence(ctx context.Context, taskID, nextID primitive.ObjectID) {
+	s.db.UpdateOne(ctx,
+		bson.M{"_id": taskID, "recurrence.next_id": nextID},
+		bson.M{"$unset": bson.M{"recurrence.next_id": ""}, "$inc": bson.M{"version": 1}},
+	)
+}
+
+// updateOpenOccurrences sets fields on the occurrences of the series that have no next occurrence yet
+func (s *TaskService) updateOpenOccurrences(ctx context.Context, seriesID primitive.ObjectID, set bson.M) error {
+	_, err := s.db.UpdateMany(ctx,
+		bson.M{"recurrence.series_id": seriesID, "recurrence.next_id": bson.M{"$exists": false}},
+		bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+	)
+	return err
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"todoerbk/models"
+)
+
+func TestNextDateEndedSeries(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		rule       string
+		due        time.Time
+		occurrence int
+		ok         bool
+	}{
+		{"COUNT left", "FREQ=DAILY;COUNT=3", start.AddDate(0, 0, 1), 2, true},
+		{"COUNT used up", "FREQ=DAILY;COUNT=3", start.AddDate(0, 0, 2), 3, false},
+		{"UNTIL ahead", "FREQ=WEEKLY;UNTIL=20240131", start.AddDate(0, 0, 21), 4, true},
+		{"UNTIL passed", "FREQ=WEEKLY;UNTIL=20240131", start.AddDate(0, 0, 28), 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurrence := &models.Recurrence{Rule: tt.rule, Start: start, Occurrence: tt.occurrence}
+			_, ok, err := nextDate(recurrence, tt.due)
+			if err != nil {
+				t.Fatalf("nextDate error: %v", err)
+			}
+			if ok != tt.ok {
+				t.Errorf("nextDate(%q, occurrence %d) ok = %v, want %v", tt.rule, tt.occurrence, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNextDateInvalidRule(t *testing.T) {
+	recurrence := &models.Recurrence{Rule: "FREQ=HOURLY", Start: time.Now()}
+	if _, _, err := nextDate(recurrence, time.Now()); err == nil {
+		t.Error("nextDate accepted an invalid rule")
+	}
+}
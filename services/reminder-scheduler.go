@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const reminderBatchSize = 100
+
+// ReminderScheduler periodically sends the task reminders that came due
+type ReminderScheduler struct {
+	TaskService         *TaskService
+	BoardService        *BoardService
+	NotificationService *NotificationService
+	interval            time.Duration
+}
+
+func NewReminderScheduler(taskService *TaskService, boardService *BoardService, notificationService *NotificationService, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{
+		TaskService:         taskService,
+		BoardService:        boardService,
+		NotificationService: notificationService,
+		interval:            interval,
+	}
+}
+
+// Run blocks until ctx is cancelled, checking for due reminders every interval
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SendDueReminders(ctx, time.Now().UTC()); err != nil {
+				log.Printf("Error sending task reminders: %v", err)
+			}
+		}
+	}
+}
+
+func (s *ReminderScheduler) SendDueReminders(ctx context.Context, now time.Time) error {
+	tasks, err := s.TaskService.GetTasksWithDueReminders(ctx, now, reminderBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		claimed, err := s.TaskService.ClaimDueReminders(ctx, task.ID, now)
+		if err != nil {
+			log.Printf("Error claiming reminders of task %s: %v", task.ID.Hex(), err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		for _, userID := range s.recipients(ctx, task) {
+			taskID, boardID := task.ID, task.BoardID
+			err := s.NotificationService.Notify(ctx, &models.Notification{
+				UserID:  userID,
+				Type:    models.TaskReminderNotification,
+				Message: fmt.Sprintf("Task \"%s\" is due %s", task.Title, task.DueDate.Format(time.RFC1123)),
+				TaskID:  &taskID,
+				BoardID: &boardID,
+			})
+			if err != nil {
+				log.Printf("Error notifying reminder of task %s: %v", task.ID.Hex(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// recipients are the users reminded about a task: its assignees, or the board owner when nobody is assigned
+func (s *ReminderScheduler) recipients(ctx context.Context, task models.Task) []primitive.ObjectID {
+	if len(task.Assignees) > 0 {
+		return task.Assignees
+	}
+
+	board, err := s.BoardService.GetBoardById(ctx, task.BoardID.Hex())
+	if err != nil {
+		log.Printf("Unable to find board of task %s: %v", task.ID.Hex(), err)
+		return nil
+	}
+	return []primitive.ObjectID{board.OwnerID}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const rolloverBatchSize = 20
+
+// rolloverRetryDelay is how long a board whose automatic rollover failed waits before the next try
+const rolloverRetryDelay = time.Hour
+
+// RolloverScheduler periodically rolls over the boards with automatic rollover once they end
+type RolloverScheduler struct {
+	RolloverService *RolloverService
+	interval        time.Duration
+}
+
+func NewRolloverScheduler(rolloverService *RolloverService, interval time.Duration) *RolloverScheduler {
+	return &RolloverScheduler{RolloverService: rolloverService, interval: interval}
+}
+
+// Run blocks until ctx is cancelled, rolling over the ended boards every interval.
+// Boards whose rollover failed stay open and are retried on the next run.
+func (s *RolloverScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, errs := s.RolloverService.RolloverDue(ctx, time.Now().UTC(), rolloverBatchSize, rolloverRetryDelay)
+			for _, err := range errs {
+				log.Printf("Error rolling over board: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+// maxRecurrenceSearch bounds how far ahead the next occurrence is looked for
+const maxRecurrenceSearch = 10 * 366
+
+type recurrenceFreq string
+
+const (
+	freqDaily   recurrenceFreq = "DAILY"
+	freqWeekly  recurrenceFreq = "WEEKLY"
+	freqMonthly recurrenceFreq = "MONTHLY"
+	freqYearly  recurrenceFreq = "YEARLY"
+)
+
+// weekdayRule is a BYDAY entry, Ordinal is the nth weekday of the month (negative from the end), 0 for every one
+type weekdayRule struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// RecurrenceRule is the supported subset of the iCalendar RRULE: FREQ, INTERVAL, COUNT,
+// UNTIL, BYDAY, BYMONTHDAY and BYMONTH, e.g. "FREQ=WEEKLY;BYDAY=MO,TH" or "FREQ=MONTHLY;BYDAY=1MO"
+type RecurrenceRule struct {
+	Freq       recurrenceFreq
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []weekdayRule
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func invalidRule(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRecurrence, fmt.Sprintf(format, args...))
+}
+
+// ParseRecurrenceRule parses a rule like "RRULE:FREQ=MONTHLY;BYDAY=1MO", the RRULE: prefix is optional
+func ParseRecurrenceRule(rule string) (*RecurrenceRule, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	parsed := &RecurrenceRule{Interval: 1}
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, invalidRule("%q is not KEY=VALUE", part)
+		}
+
+		switch key {
+		case "FREQ":
+			parsed.Freq = recurrenceFreq(value)
+			if parsed.Freq != freqDaily && parsed.Freq != freqWeekly && parsed.Freq != freqMonthly && parsed.Freq != freqYearly {
+				return nil, invalidRule("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, invalidRule("INTERVAL must be a positive number")
+			}
+			parsed.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, invalidRule("COUNT must be a positive number")
+			}
+			parsed.Count = n
+		case "UNTIL":
+			until, err := parseRuleDate(value)
+			if err != nil {
+				return nil, invalidRule("UNTIL must be a date like 20240131 or 20240131T090000Z")
+			}
+			parsed.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[day[max(len(day)-2, 0):]]
+				if !ok {
+					return nil, invalidRule("unknown weekday in BYDAY %q", day)
+				}
+				entry := weekdayRule{Weekday: weekday}
+				if prefix := day[:len(day)-2]; prefix != "" {
+					n, err := strconv.Atoi(prefix)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, invalidRule("invalid ordinal in BYDAY %q", day)
+					}
+					entry.Ordinal = n
+				}
+				parsed.ByDay = append(parsed.ByDay, entry)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, invalidRule("invalid BYMONTHDAY %q", day)
+				}
+				parsed.ByMonthDay = append(parsed.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(month)
+				if err != nil || n < 1 || n > 12 {
+					return nil, invalidRule("invalid BYMONTH %q", month)
+				}
+				parsed.ByMonth = append(parsed.ByMonth, time.Month(n))
+			}
+		default:
+			return nil, invalidRule("%s is not supported", key)
+		}
+	}
+
+	if parsed.Freq == "" {
+		return nil, invalidRule("FREQ is required")
+	}
+	if parsed.Count > 0 && parsed.Until != nil {
+		return nil, invalidRule("COUNT and UNTIL cannot be used together")
+	}
+	for _, day := range parsed.ByDay {
+		if day.Ordinal != 0 && parsed.Freq != freqMonthly && parsed.Freq != freqYearly {
+			return nil, invalidRule("BYDAY ordinals need FREQ=MONTHLY or FREQ=YEARLY")
+		}
+	}
+	return parsed, nil
+}
+
+func parseRuleDate(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// A date-only UNTIL includes the whole day
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+// Next returns the first occurrence after the given one. start is the first occurrence of the
+// series, it sets the time of day and the period the interval counts from. occurrence is the
+// 1-based number of the given occurrence, used by COUNT. ok is false when the series is over.
+func (rule *RecurrenceRule) Next(start, after time.Time, occurrence int) (time.Time, bool) {
+	if rule.Count > 0 && occurrence >= rule.Count {
+		return time.Time{}, false
+	}
+
+	start = start.UTC()
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	clock := start.Sub(startDay)
+	after = after.UTC()
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= maxRecurrenceSearch; i++ {
+		candidate := day.Add(clock)
+		if candidate.After(after) && !candidate.Before(start) && rule.matches(startDay, day) {
+			if rule.Until != nil && candidate.After(*rule.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
+
+// matches tells if the day belongs to the series that starts on startDay
+func (rule *RecurrenceRule) matches(startDay, day time.Time) bool {
+	switch rule.Freq {
+	case freqDaily:
+		days := int(day.Sub(startDay).Hours() / 24)
+		return days%rule.Interval == 0 && rule.matchesWeekday(day)
+	case freqWeekly:
+		weeks := int(weekStart(day).Sub(weekStart(startDay)).Hours() / (24 * 7))
+		if weeks%rule.Interval != 0 {
+			return false
+		}
+		if len(rule.ByDay) == 0 {
+			return day.Weekday() == startDay.Weekday()
+		}
+		return rule.matchesWeekday(day)
+	case freqMonthly:
+		months := (day.Year()-startDay.Year())*12 + int(day.Month()-startDay.Month())
+		return months%rule.Interval == 0 && rule.matchesDayOfMonth(startDay, day)
+	case freqYearly:
+		if (day.Year()-startDay.Year())%rule.Interval != 0 {
+			return false
+		}
+		months := rule.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{startDay.Month()}
+		}
+		for _, month := range months {
+			if day.Month() == month {
+				return rule.matchesDayOfMonth(startDay, day)
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// matchesWeekday applies a BYDAY without ordinals, no BYDAY matches every day
+func (rule *RecurrenceRule) matchesWeekday(day time.Time) bool {
+	if len(rule.ByDay) == 0 {
+		return true
+	}
+	for _, entry := range rule.ByDay {
+		if entry.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDayOfMonth applies BYMONTHDAY and BYDAY inside a month, defaulting to the day of month of the start
+func (rule *RecurrenceRule) matchesDayOfMonth(startDay, day time.Time) bool {
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	if len(rule.ByMonthDay) > 0 {
+		for _, n := range rule.ByMonthDay {
+			if n == day.Day() || (n < 0 && lastDay+n+1 == day.Day()) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(rule.ByDay) > 0 {
+		for _, entry := range rule.ByDay {
+			if entry.Weekday != day.Weekday() {
+				continue
+			}
+			nth := (day.Day()-1)/7 + 1
+			nthFromEnd := -((lastDay-day.Day())/7 + 1)
+			if entry.Ordinal == 0 || entry.Ordinal == nth || entry.Ordinal == nthFromEnd {
+				return true
+			}
+		}
+		return false
+	}
+	return day.Day() == startDay.Day()
+}
+
+// weekStart returns the Monday of the week of the day, weeks start on Monday as WKST=MO
+func weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
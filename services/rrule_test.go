@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	until := time.Date(2024, 1, 10, 23, 59, 59, 0, time.UTC)
+	untilTime := time.Date(2024, 1, 10, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rule string
+		want *RecurrenceRule
+	}{
+		{"daily", "FREQ=DAILY", &RecurrenceRule{Freq: freqDaily, Interval: 1}},
+		{"prefix and lower case", "rrule:freq=weekly;interval=2;byday=mo,th", &RecurrenceRule{
+			Freq: freqWeekly, Interval: 2,
+			ByDay: []weekdayRule{{Weekday: time.Monday}, {Weekday: time.Thursday}},
+		}},
+		{"BYDAY ordinals", "FREQ=MONTHLY;BYDAY=1MO,-1FR,+2WE", &RecurrenceRule{
+			Freq: freqMonthly, Interval: 1,
+			ByDay: []weekdayRule{{1, time.Monday}, {-1, time.Friday}, {2, time.Wednesday}},
+		}},
+		{"negative BYMONTHDAY", "FREQ=MONTHLY;BYMONTHDAY=1,-1", &RecurrenceRule{
+			Freq: freqMonthly, Interval: 1, ByMonthDay: []int{1, -1},
+		}},
+		{"BYMONTH", "FREQ=YEARLY;BYMONTH=3,10;BYDAY=-1SU", &RecurrenceRule{
+			Freq: freqYearly, Interval: 1,
+			ByDay:   []weekdayRule{{-1, time.Sunday}},
+			ByMonth: []time.Month{time.March, time.October},
+		}},
+		{"COUNT", "FREQ=DAILY;COUNT=3", &RecurrenceRule{Freq: freqDaily, Interval: 1, Count: 3}},
+		{"UNTIL date includes the whole day", "FREQ=DAILY;UNTIL=20240110", &RecurrenceRule{Freq: freqDaily, Interval: 1, Until: &until}},
+		{"UNTIL date and time", "FREQ=DAILY;UNTIL=20240110T093000Z", &RecurrenceRule{Freq: freqDaily, Interval: 1, Until: &untilTime}},
+		{"empty parts", "FREQ=DAILY;;", &RecurrenceRule{Freq: freqDaily, Interval: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q) error: %v", tt.rule, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRecurrenceRule(%q) = %+v, want %+v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleInvalid(t *testing.T) {
+	rules := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ",
+		"FREQ=",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;UNTIL=2024-01-10",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240110",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=MO,",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;BYSETPOS=1",
+	}
+	for _, rule := range rules {
+		if _, err := ParseRecurrenceRule(rule); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("ParseRecurrenceRule(%q) error = %v, want ErrInvalidRecurrence", rule, err)
+		}
+	}
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		rule       string
+		start      time.Time
+		after      time.Time
+		occurrence int
+		want       time.Time
+		ok         bool
+	}{
+		{"daily", "FREQ=DAILY", at(2024, 1, 1), at(2024, 1, 1), 1, at(2024, 1, 2), true},
+		{"daily interval", "FREQ=DAILY;INTERVAL=2", at(2024, 1, 1), at(2024, 1, 1), 1, at(2024, 1, 3), true},
+		{"daily late completion keeps the interval", "FREQ=DAILY;INTERVAL=2", at(2024, 1, 1), at(2024, 1, 4), 1, at(2024, 1, 5), true},
+		{"weekly on the weekday of the start", "FREQ=WEEKLY;INTERVAL=2", at(2024, 1, 3), at(2024, 1, 3), 1, at(2024, 1, 17), true},
+		{"weekly BYDAY", "FREQ=WEEKLY;BYDAY=MO,TH", at(2024, 1, 1), at(2024, 1, 1), 1, at(2024, 1, 4), true},
+		{"weekly BYDAY next week", "FREQ=WEEKLY;BYDAY=MO,TH", at(2024, 1, 1), at(2024, 1, 4), 2, at(2024, 1, 8), true},
+		{"monthly on the day of the start skips short months", "FREQ=MONTHLY", at(2024, 1, 31), at(2024, 1, 31), 1, at(2024, 3, 31), true},
+		{"first monday", "FREQ=MONTHLY;BYDAY=1MO", at(2024, 1, 1), at(2024, 1, 1), 1, at(2024, 2, 5), true},
+		{"second wednesday", "FREQ=MONTHLY;BYDAY=2WE", at(2024, 1, 10), at(2024, 1, 10), 1, at(2024, 2, 14), true},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", at(2024, 1, 26), at(2024, 1, 26), 1, at(2024, 2, 23), true},
+		{"last day of a leap february", "FREQ=MONTHLY;BYMONTHDAY=-1", at(2024, 1, 31), at(2024, 1, 31), 1, at(2024, 2, 29), true},
+		{"last day after february", "FREQ=MONTHLY;BYMONTHDAY=-1", at(2024, 1, 31), at(2024, 2, 29), 2, at(2024, 3, 31), true},
+		{"second to last day", "FREQ=MONTHLY;BYMONTHDAY=-2", at(2023, 1, 30), at(2023, 1, 30), 1, at(2023, 2, 27), true},
+		{"yearly last sunday of march", "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU", at(2024, 3, 31), at(2024, 3, 31), 1, at(2025, 3, 30), true},
+		{"yearly on the date of the start", "FREQ=YEARLY", at(2024, 2, 29), at(2024, 2, 29), 1, at(2028, 2, 29), true},
+		{"COUNT not reached", "FREQ=DAILY;COUNT=3", at(2024, 1, 1), at(2024, 1, 2), 2, at(2024, 1, 3), true},
+		{"COUNT reached", "FREQ=DAILY;COUNT=3", at(2024, 1, 1), at(2024, 1, 3), 3, time.Time{}, false},
+		{"UNTIL date includes its day", "FREQ=DAILY;UNTIL=20240110", at(2024, 1, 1), at(2024, 1, 9), 9, at(2024, 1, 10), true},
+		{"UNTIL date passed", "FREQ=DAILY;UNTIL=20240110", at(2024, 1, 1), at(2024, 1, 10), 10, time.Time{}, false},
+		{"UNTIL before the time of day", "FREQ=DAILY;UNTIL=20240110T080000Z", at(2024, 1, 1), at(2024, 1, 9), 9, time.Time{}, false},
+		{"never matches", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", at(2024, 2, 1), at(2024, 2, 1), 1, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q) error: %v", tt.rule, err)
+			}
+			got, ok := rule.Next(tt.start, tt.after, tt.occurrence)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("Next(%v, %v, %d) = %v, %v, want %v, %v", tt.start, tt.after, tt.occurrence, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strings"
+	"todoerbk/models"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const snippetRadius = 60
+
+type SearchService struct {
+	BoardService *BoardService
+	TaskService  *TaskService
+}
+
+func NewSearchService(boardService *BoardService, taskService *TaskService) *SearchService {
+	return &SearchService{BoardService: boardService, TaskService: taskService}
+}
+
+// Search looks for boards and tasks matching text on every board the user can read.
+// Results are ranked by text score and carry an HTML snippet with the matches wrapped in <mark>.
+func (s *SearchService) Search(ctx context.Context, userID string, text string, limit int64) ([]models.SearchResult, error) {
+	limit = pageLimit(limit)
+	results := []models.SearchResult{}
+
+	boards, err := s.BoardService.searchBoards(ctx, userID, text, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, board := range boards {
+		results = append(results, models.SearchResult{
+			Type:    "board",
+			ID:      board.ID.Hex(),
+			Title:   board.Title,
+			Snippet: highlight(board.Title, text),
+			Score:   board.Score,
+			Board:   board.Board,
+		})
+	}
+
+	boardIDs, err := s.BoardService.GetAccessibleBoardIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(boardIDs) > 0 {
+		tasks, err := s.TaskService.searchTasks(ctx, boardIDs, text, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		parents, err := s.parentBoards(ctx, tasks)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			results = append(results, models.SearchResult{
+				Type:    "task",
+				ID:      task.ID.Hex(),
+				Title:   task.Title,
+				Snippet: highlight(task.Title, text),
+				Score:   task.Score,
+				Board:   parents[task.BoardID],
+				Task:    &task.Task,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if int64(len(results)) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (s *SearchService) parentBoards(ctx context.Context, tasks []scoredTask) (map[primitive.ObjectID]models.Board, error) {
+	seen := map[primitive.ObjectID]bool{}
+	var ids []primitive.ObjectID
+	for _, task := range tasks {
+		if !seen[task.BoardID] {
+			seen[task.BoardID] = true
+			ids = append(ids, task.BoardID)
+		}
+	}
+
+	parents := map[primitive.ObjectID]models.Board{}
+	if len(ids) == 0 {
+		return parents, nil
+	}
+	boards, err := s.BoardService.GetBoardsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, board := range boards {
+		parents[board.ID] = board
+	}
+	return parents, nil
+}
+
+// searchTerms splits the query the same way the text index does, ignoring negations and quotes
+func searchTerms(text string) []string {
+	var terms []string
+	for _, field := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	}) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+// matchesTerm approximates the stemming of the text index: "planning" matches "plan" and the other way around
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) || (len(word) >= 3 && strings.HasPrefix(term, word)) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight escapes value, wraps the words matching the query in <mark> and trims it around the first match
+func highlight(value string, text string) string {
+	terms := searchTerms(text)
+	runes := []rune(value)
+
+	start, end := 0, len(runes)
+	first := -1
+	var b strings.Builder
+	i := 0
+	for i < len(runes) {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		if matchesTerm(string(runes[i:j]), terms) {
+			first = i
+			break
+		}
+		i = j
+	}
+	if first > snippetRadius {
+		start = first - snippetRadius
+	}
+	if end-start > 2*snippetRadius {
+		end = start + 2*snippetRadius
+	}
+
+	if start > 0 {
+		b.WriteString("…")
+	}
+	i = start
+	for i < end {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		j := i
+		for j < end && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		word := html.EscapeString(string(runes[i:j]))
+		if matchesTerm(string(runes[i:j]), terms) {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTasksByIds returns the tasks that exist among the ids, in no particular order
+func (s *TaskService) GetTasksByIds(ctx context.Context, ids []primitive.ObjectID) ([]models.Task, error) {
+	return s.findAll(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// updateTasks applies the update to every task of ids in one UpdateMany, bumping their versions
+func (s *TaskService) updateTasks(ctx context.Context, ids []primitive.ObjectID, update bson.M) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+	}
+	set["updated_at"] = time.Now().UTC()
+	update["$set"] = set
+	update["$inc"] = bson.M{"version": 1}
+	_, err := s.db.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, update)
+	return err
+}
+
+func (s *TaskService) SetPriority(ctx context.Context, ids []primitive.ObjectID, priority models.TaskPriority) error {
+	return s.updateTasks(ctx, ids, bson.M{"$set": bson.M{"priority": priority}})
+}
+
+func (s *TaskService) AddLabels(ctx context.Context, ids []primitive.ObjectID, labelIDs []primitive.ObjectID) error {
+	return s.updateTasks(ctx, ids, bson.M{"$addToSet": bson.M{"label_ids": bson.M{"$each": labelIDs}}})
+}
+
+func (s *TaskService) RemoveLabels(ctx context.Context, ids []primitive.ObjectID, labelIDs []primitive.ObjectID) error {
+	return s.updateTasks(ctx, ids, bson.M{"$pull": bson.M{"label_ids": bson.M{"$in": labelIDs}}})
+}
+
+func (s *TaskService) AddAssignees(ctx context.Context, ids []primitive.ObjectID, assigneeIDs []primitive.ObjectID) error {
+	return s.updateTasks(ctx, ids, bson.M{"$addToSet": bson.M{"assignees": bson.M{"$each": assigneeIDs}}})
+}
+
+func (s *TaskService) RemoveAssignees(ctx context.Context, ids []primitive.ObjectID, assigneeIDs []primitive.ObjectID) error {
+	return s.updateTasks(ctx, ids, bson.M{"$pull": bson.M{"assignees": bson.M{"$in": assigneeIDs}}})
+}
+
+// DeleteTasks deletes the tasks of ids in one DeleteMany and drops them from the blockers of other tasks
+func (s *TaskService) DeleteTasks(ctx context.Context, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.db.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	deleted := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		deleted = append(deleted, id)
+	}
+	return s.removeFromBlockers(ctx, deleted)
+}
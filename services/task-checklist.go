@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+var ErrInvalidChecklistOrder = errors.New("the new order must contain every checklist item exactly once")
+
+func checklistProgress(items []models.ChecklistItem) models.ChecklistProgress {
+	progress := models.ChecklistProgress{Total: len(items)}
+	for _, item := range items {
+		if item.Done {
+			progress.Done++
+		}
+	}
+	return progress
+}
+
+// modifyTask applies modify to the latest stored task and saves it with the version check.
+// When another request wins the race it reads the task again and retries, so partial
+// changes like checklist edits never need an If-Match from the client.
+func (s *TaskService) modifyTask(ctx context.Context, id string, modify func(*models.Task) error) (*models.Task, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		task, err := s.GetTaskById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := modify(task); err != nil {
+			return nil, err
+		}
+		task.UpdatedAt = time.Now().UTC()
+
+		err = s.UpdateTask(ctx, id, task)
+		if errors.Is(err, ErrVersionMismatch) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return task, nil
+	}
+	return nil, ErrVersionMismatch
+}
+
+func checklistItemIndex(task *models.Task, itemID primitive.ObjectID) int {
+	for i, item := range task.Checklist {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *TaskService) AddChecklistItem(ctx context.Context, taskID string, item models.ChecklistItem) (*models.Task, error) {
+	item.ID = primitive.NewObjectID()
+	return s.modifyTask(ctx, taskID, func(task *models.Task) error {
+		task.Checklist = append(task.Checklist, item)
+		return nil
+	})
+}
+
+func (s *TaskService) UpdateChecklistItem(ctx context.Context, taskID string, itemID primitive.ObjectID, update models.ChecklistItemUpdateRequest) (*models.Task, error) {
+	return s.modifyTask(ctx, taskID, func(task *models.Task) error {
+		i := checklistItemIndex(task, itemID)
+		if i < 0 {
+			return ErrChecklistItemNotFound
+		}
+		if update.Text != nil {
+			task.Checklist[i].Text = *update.Text
+		}
+		if update.Done != nil {
+			task.Checklist[i].Done = *update.Done
+		}
+		if update.AssigneeID != nil {
+			task.Checklist[i].AssigneeID = update.AssigneeID
+		}
+		return nil
+	})
+}
+
+func (s *TaskService) ToggleChecklistItem(ctx context.Context, taskID string, itemID primitive.ObjectID) (*models.Task, error) {
+	return s.modifyTask(ctx, taskID, func(task *models.Task) error {
+		i := checklistItemIndex(task, itemID)
+		if i < 0 {
+			return ErrChecklistItemNotFound
+		}
+		task.Checklist[i].Done = !task.Checklist[i].Done
+		return nil
+	})
+}
+
+func (s *TaskService) ReorderChecklist(ctx context.Context, taskID string, itemIDs []primitive.ObjectID) (*models.Task, error) {
+	return s.modifyTask(ctx, taskID, func(task *models.Task) error {
+		if len(itemIDs) != len(task.Checklist) {
+			return ErrInvalidChecklistOrder
+		}
+		reordered := make([]models.ChecklistItem, 0, len(itemIDs))
+		used := map[primitive.ObjectID]bool{}
+		for _, itemID := range itemIDs {
+			i := checklistItemIndex(task, itemID)
+			if i < 0 || used[itemID] {
+				return ErrInvalidChecklistOrder
+			}
+			used[itemID] = true
+			reordered = append(reordered, task.Checklist[i])
+		}
+		task.Checklist = reordered
+		return nil
+	})
+}
+
+func (s *TaskService) RemoveChecklistItem(ctx context.Context, taskID string, itemID primitive.ObjectID) (*models.Task, error) {
+	return s.modifyTask(ctx, taskID, func(task *models.Task) error {
+		i := checklistItemIndex(task, itemID)
+		if i < 0 {
+			return ErrChecklistItemNotFound
+		}
+		task.Checklist = append(task.Checklist[:i], task.Checklist[i+1:]...)
+		return nil
+	})
+}
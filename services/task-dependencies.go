@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"todoerbk/database"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrSelfDependency = errors.New("a task cannot block itself")
+var ErrDependencyCycle = errors.New("the dependency would create a cycle")
+var ErrDependenciesBusy = errors.New("the dependencies are being changed by other requests, try again")
+
+// wouldCreateCycle tells if making taskID blocked by blockerID closes a loop,
+// which happens when taskID already blocks blockerID directly or transitively
+func (s *TaskService) wouldCreateCycle(ctx context.Context, taskID, blockerID primitive.ObjectID) (bool, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"_id": blockerID}},
+		{"$graphLookup": bson.M{
+			"from":             database.TasksCollection,
+			"startWith":        "$blocked_by",
+			"connectFromField": "blocked_by",
+			"connectToField":   "_id",
+			"as":               "blockers",
+		}},
+		{"$project": bson.M{"_id": 0, "blockers": "$blockers._id"}},
+	}
+
+	cursor, err := s.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Blockers []primitive.ObjectID `bson:"blockers"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return false, err
+	}
+	for _, row := range rows {
+		for _, id := range row.Blockers {
+			if id == taskID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// AddDependency records that the task is blocked by blocker, rejecting self links and cycles.
+// Dependencies only link boards of the same owner, so the check and the write run under a lock
+// of the owner: two requests linking the same tasks both ways cannot both pass the check.
+func (s *TaskService) AddDependency(ctx context.Context, ownerID, taskID, blockerID primitive.ObjectID) (*models.Task, error) {
+	if taskID == blockerID {
+		return nil, ErrSelfDependency
+	}
+	unlock, err := s.lock(ctx, bson.D{{Key: "dependencies_of", Value: ownerID}}, ErrDependenciesBusy)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	cycle, err := s.wouldCreateCycle(ctx, taskID, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, ErrDependencyCycle
+	}
+
+	return s.modifyTask(ctx, taskID.Hex(), func(task *models.Task) error {
+		for _, id := range task.BlockedBy {
+			if id == blockerID {
+				return nil
+			}
+		}
+		task.BlockedBy = append(task.BlockedBy, blockerID)
+		return nil
+	})
+}
+
+func (s *TaskService) RemoveDependency(ctx context.Context, taskID, blockerID primitive.ObjectID) (*models.Task, error) {
+	return s.modifyTask(ctx, taskID.Hex(), func(task *models.Task) error {
+		for i, id := range task.BlockedBy {
+			if id == blockerID {
+				task.BlockedBy = append(task.BlockedBy[:i], task.BlockedBy[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+func (s *TaskService) findAll(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Task, error) {
+	cursor, err := s.db.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetBlockers returns the tasks that block the task
+func (s *TaskService) GetBlockers(ctx context.Context, task *models.Task) ([]models.Task, error) {
+	if len(task.BlockedBy) == 0 {
+		return []models.Task{}, nil
+	}
+	return s.findAll(ctx, bson.M{"_id": bson.M{"$in": task.BlockedBy}})
+}
+
+// GetUnfinishedBlockers returns the blockers of the task that are not in a done column yet
+func (s *TaskService) GetUnfinishedBlockers(ctx context.Context, task *models.Task) ([]models.Task, error) {
+	if len(task.BlockedBy) == 0 {
+		return []models.Task{}, nil
+	}
+	return s.findAll(ctx, bson.M{"_id": bson.M{"$in": task.BlockedBy}, "status_category": bson.M{"$ne": models.CategoryDone}})
+}
+
+// GetBlockedTasks returns the tasks the task blocks
+func (s *TaskService) GetBlockedTasks(ctx context.Context, taskID primitive.ObjectID) ([]models.Task, error) {
+	return s.findAll(ctx, bson.M{"blocked_by": taskID})
+}
+
+// GetBoardDependencyGraph returns the tasks of the board plus the tasks of other boards linked to them
+func (s *TaskService) GetBoardDependencyGraph(ctx context.Context, boardID primitive.ObjectID) (*models.DependencyGraph, error) {
+	tasks, err := s.findAll(ctx, bson.M{"board_id": boardID})
+	if err != nil {
+		return nil, err
+	}
+
+	onBoard := map[primitive.ObjectID]bool{}
+	ids := []primitive.ObjectID{}
+	for _, task := range tasks {
+		onBoard[task.ID] = true
+		ids = append(ids, task.ID)
+	}
+
+	// Tasks on other boards that block or are blocked by the tasks of this board
+	externalIDs := []primitive.ObjectID{}
+	for _, task := range tasks {
+		for _, blocker := range task.BlockedBy {
+			if !onBoard[blocker] {
+				externalIDs = append(externalIDs, blocker)
+			}
+		}
+	}
+	external, err := s.findAll(ctx, bson.M{"$or": []bson.M{
+		{"_id": bson.M{"$in": externalIDs}},
+		{"blocked_by": bson.M{"$in": ids}, "board_id": bson.M{"$ne": boardID}},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.DependencyGraph{Nodes: append(tasks, external...), Edges: []models.DependencyEdge{}}
+	inGraph := map[primitive.ObjectID]bool{}
+	for _, node := range graph.Nodes {
+		inGraph[node.ID] = true
+	}
+	for _, node := range graph.Nodes {
+		for _, blocker := range node.BlockedBy {
+			if inGraph[blocker] && (onBoard[node.ID] || onBoard[blocker]) {
+				graph.Edges = append(graph.Edges, models.DependencyEdge{From: blocker, To: node.ID})
+			}
+		}
+	}
+	return graph, nil
+}
+
+// removeFromBlockers drops deleted tasks from the blocked_by lists that reference them
+func (s *TaskService) removeFromBlockers(ctx context.Context, ids []interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.db.UpdateMany(ctx,
+		bson.M{"blocked_by": bson.M{"$in": ids}},
+		bson.M{"$pull": bson.M{"blocked_by": bson.M{"$in": ids}}, "$inc": bson.M{"version": 1}},
+	)
+	return err
+}
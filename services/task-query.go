@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taskQueryFilter translates the typed task query into a Mongo filter
+func taskQueryFilter(query models.TaskQuery) bson.M {
+	filter := bson.M{}
+
+	if len(query.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": query.Statuses}
+	}
+	if len(query.Priorities) > 0 {
+		filter["priority"] = bson.M{"$in": query.Priorities}
+	}
+	if query.TitleContains != "" {
+		filter["title"] = bson.M{"$regex": regexp.QuoteMeta(query.TitleContains), "$options": "i"}
+	}
+	if len(query.LabelIDs) > 0 {
+		if query.MatchAllLabels {
+			filter["label_ids"] = bson.M{"$all": query.LabelIDs}
+		} else {
+			filter["label_ids"] = bson.M{"$in": query.LabelIDs}
+		}
+	}
+	if query.BoardID != nil {
+		filter["board_id"] = *query.BoardID
+	}
+	if r := dateRange(query.CreatedFrom, query.CreatedTo); r != nil {
+		filter["created_at"] = r
+	}
+	if r := dateRange(query.UpdatedFrom, query.UpdatedTo); r != nil {
+		filter["updated_at"] = r
+	}
+	if query.Overdue {
+		filter["due_date"] = bson.M{"$lt": time.Now().UTC()}
+		filter["$and"] = []bson.M{{"status_category": bson.M{"$ne": models.CategoryDone}}}
+	}
+
+	return filter
+}
+
+func dateRange(from, to *time.Time) bson.M {
+	r := bson.M{}
+	if from != nil {
+		r["$gte"] = *from
+	}
+	if to != nil {
+		r["$lte"] = *to
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	return r
+}
+
+// priorityRankExpr mirrors models.TaskPriority.Rank so priorities sort HIGH, MEDIUM, LOW
+func priorityRankExpr() bson.M {
+	branches := []bson.M{}
+	for _, p := range []models.TaskPriority{models.HIGH, models.MEDIUM, models.LOW} {
+		branches = append(branches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$priority", p}},
+			"then": p.Rank(),
+		})
+	}
+	return bson.M{"$switch": bson.M{
+		"branches": branches,
+		"default":  models.TaskPriority("").Rank(),
+	}}
+}
+
+// taskSortField returns the document field the query sorts on
+func taskSortField(query models.TaskQuery) string {
+	switch query.SortBy {
+	case models.SortByPriority:
+		return "priority_rank"
+	case models.SortByPosition:
+		return "position"
+	case "":
+		return string(models.SortByCreatedAt)
+	default:
+		return string(query.SortBy)
+	}
+}
+
+// taskSortKey returns the value of the sort field for a task, used to build the next cursor
+func taskSortKey(query models.TaskQuery, columns []models.TaskStatus) func(models.Task) (interface{}, primitive.ObjectID) {
+	return func(task models.Task) (interface{}, primitive.ObjectID) {
+		switch query.SortBy {
+		case models.SortByPriority:
+			return task.Priority.Rank(), task.ID
+		case models.SortByUpdatedAt:
+			return task.UpdatedAt, task.ID
+		case models.SortByTitle:
+			return task.Title, task.ID
+		case models.SortByPosition:
+			return positionKey(task, columns), task.ID
+		default:
+			return task.CreatedAt, task.ID
+		}
+	}
+}
+
+// findTasks reads one page of tasks matching base and the query, ordered by the query sort and then _id.
+// columns is the status order of the position sort, the default workflow when nil.
+func (s *TaskService) findTasks(ctx context.Context, base bson.M, query models.TaskQuery, page models.PageRequest, columns []models.TaskStatus) ([]models.Task, string, error) {
+	if columns == nil {
+		columns = models.Board{Workflow: models.DefaultWorkflow()}.Statuses()
+	}
+	limit := pageLimit(page.Limit)
+	field := taskSortField(query)
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+
+	filter := taskQueryFilter(query)
+	for k, v := range base {
+		filter[k] = v
+	}
+
+	pipeline := []bson.M{{"$match": filter}}
+	switch query.SortBy {
+	case models.SortByPriority:
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"priority_rank": priorityRankExpr()}})
+	case models.SortByPosition:
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{"position": positionExpr(columns)}})
+	}
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		pipeline = append(pipeline, bson.M{"$match": afterCursor(field, query.SortDesc, c)})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}},
+		bson.M{"$limit": limit + 1},
+	)
+	switch query.SortBy {
+	case models.SortByPriority:
+		pipeline = append(pipeline, bson.M{"$project": bson.M{"priority_rank": 0}})
+	case models.SortByPosition:
+		pipeline = append(pipeline, bson.M{"$project": bson.M{"position": 0}})
+	}
+
+	cursor, err := s.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, "", err
+	}
+
+	return trimPage(tasks, limit, taskSortKey(query, columns))
+}
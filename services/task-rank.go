@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInvalidMove = errors.New("the neighbor tasks must be in the target column of the same board, in order")
+
+// rankDigits are the digits of the ranks, ordered as their byte values so ranks compare as strings
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const rankBase = len(rankDigits)
+
+// rankDigit returns the value of the i-th digit of the rank, missing digits count as zero
+func rankDigit(rank string, i int) int {
+	if i >= len(rank) {
+		return 0
+	}
+	return strings.IndexByte(rankDigits, rank[i])
+}
+
+// rankBetween returns a rank strictly between prev and next. An empty prev is the start of
+// the column and an empty next is its end. The result never ends with the zero digit, so
+// there is always room for another rank before it.
+func rankBetween(prev, next string) string {
+	if next == "" && prev != "" {
+		return rankAfter(prev)
+	}
+	rank := []byte{}
+	unbounded := next == ""
+	for i := 0; ; i++ {
+		low := rankDigit(prev, i)
+		high := rankBase
+		if !unbounded {
+			high = rankDigit(next, i)
+		}
+
+		if low == high {
+			rank = append(rank, rankDigits[low])
+			continue
+		}
+		if high-low > 1 {
+			return string(append(rank, rankDigits[(low+high)/2]))
+		}
+		// No digit fits between them: keep low and look for room after prev, which now has no upper bound
+		rank = append(rank, rankDigits[low])
+		unbounded = true
+	}
+}
+
+// rankAfter returns a short rank after prev, used to append to a column: it increments the
+// first digit that is not the highest one and drops the rest, so ranks grow one digit every 35 appends
+func rankAfter(prev string) string {
+	for i := 0; i < len(prev); i++ {
+		if d := rankDigit(prev, i); d < rankBase-1 {
+			return prev[:i] + string(rankDigits[d+1])
+		}
+	}
+	return prev + string(rankDigits[1])
+}
+
+// columnIndex returns the position of the status in the column order as a fixed width
+// string, statuses missing from the order go last
+func columnIndex(columns []models.TaskStatus, status models.TaskStatus) string {
+	for i, column := range columns {
+		if column == status {
+			return fmt.Sprintf("%02d", i)
+		}
+	}
+	return fmt.Sprintf("%02d", len(columns))
+}
+
+// positionKey mirrors positionExpr, it is the cursor value of the position sort
+func positionKey(task models.Task, columns []models.TaskStatus) string {
+	return columnIndex(columns, task.Status) + "|" + task.Rank
+}
+
+// positionExpr sorts tasks by status column, in the given column order, and then by rank inside the column
+func positionExpr(columns []models.TaskStatus) bson.M {
+	branches := []bson.M{}
+	for _, status := range columns {
+		branches = append(branches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$status", status}},
+			"then": columnIndex(columns, status),
+		})
+	}
+	index := bson.M{"$literal": columnIndex(columns, "")}
+	if len(branches) > 0 {
+		index = bson.M{"$switch": bson.M{"branches": branches, "default": columnIndex(columns, "")}}
+	}
+	return bson.M{"$concat": bson.A{index, "|", bson.M{"$ifNull": bson.A{"$rank", ""}}}}
+}
+
+// lastRank returns the highest rank in a column of a board, empty when the column is empty
+func (s *TaskService) lastRank(ctx context.Context, boardID primitive.ObjectID, status models.TaskStatus) (string, error) {
+	var task models.Task
+	opts := options.FindOne().SetSort(bson.D{{Key: "rank", Value: -1}}).SetProjection(bson.M{"rank": 1})
+	err := s.db.FindOne(ctx, bson.M{"board_id": boardID, "status": status}, opts).Decode(&task)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return task.Rank, err
+}
+
+// RankAtEnd returns the rank that places a task at the end of a column
+func (s *TaskService) RankAtEnd(ctx context.Context, boardID primitive.ObjectID, status models.TaskStatus) (string, error) {
+	last, err := s.lastRank(ctx, boardID, status)
+	if err != nil {
+		return "", err
+	}
+	return rankBetween(last, ""), nil
+}
+
+// neighborRank returns the rank of a neighbor of a move after checking it is in the target column
+func (s *TaskService) neighborRank(ctx context.Context, id *primitive.ObjectID, task *models.Task, status models.TaskStatus) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	if *id == task.ID {
+		return "", ErrInvalidMove
+	}
+	neighbor, err := s.GetTaskById(ctx, id.Hex())
+	if err != nil || neighbor.BoardID != task.BoardID || neighbor.Status != status || neighbor.Rank == "" {
+		return "", ErrInvalidMove
+	}
+	return neighbor.Rank, nil
+}
+
+// adjacentRank returns the closest rank after rank in the column, or before it when after is false,
+// leaving out the moved task. It is empty when there is none.
+func (s *TaskService) adjacentRank(ctx context.Context, task *models.Task, status models.TaskStatus, rank string, after bool) (string, error) {
+	op, order := "$lt", -1
+	if after {
+		op, order = "$gt", 1
+	}
+	var neighbor models.Task
+	opts := options.FindOne().SetSort(bson.D{{Key: "rank", Value: order}}).SetProjection(bson.M{"rank": 1})
+	err := s.db.FindOne(ctx, bson.M{
+		"board_id": task.BoardID,
+		"status":   status,
+		"_id":      bson.M{"$ne": task.ID},
+		"rank":     bson.M{op: rank},
+	}, opts).Decode(&neighbor)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return neighbor.Rank, err
+}
+
+// MoveTask puts the task in the column between the given neighbors. With a single neighbor the
+// task goes right next to it, before the task that follows it or after the one that precedes it.
+// Only the moved task is written.
+func (s *TaskService) MoveTask(ctx context.Context, taskID string, move models.MoveTaskRequest, column models.WorkflowColumn) (*models.Task, error) {
+	move.Status = column.Status
+	return s.modifyTask(ctx, taskID, func(task *models.Task) error {
+		prev, err := s.neighborRank(ctx, move.PrevID, task, move.Status)
+		if err != nil {
+			return err
+		}
+		next, err := s.neighborRank(ctx, move.NextID, task, move.Status)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case move.PrevID == nil && move.NextID == nil:
+			prev, err = s.lastRank(ctx, task.BoardID, move.Status)
+			if err != nil {
+				return err
+			}
+			if task.Status == move.Status && prev == task.Rank {
+				return nil
+			}
+		case move.NextID == nil:
+			next, err = s.adjacentRank(ctx, task, move.Status, prev, true)
+		case move.PrevID == nil:
+			prev, err = s.adjacentRank(ctx, task, move.Status, next, false)
+		case prev >= next:
+			return ErrInvalidMove
+		}
+		if err != nil {
+			return err
+		}
+
+		task.Status = column.Status
+		task.StatusCategory = column.Category
+		task.Rank = rankBetween(prev, next)
+		return nil
+	})
+}
+
+// EnsureRanks gives a rank to the tasks stored before ranks existed, keeping their creation
+// order inside each column after the tasks that already have one
+func (s *TaskService) EnsureRanks(ctx context.Context) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.db.Find(ctx, bson.M{"$or": []bson.M{{"rank": bson.M{"$exists": false}}, {"rank": ""}}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	last := map[string]string{}
+	for cursor.Next(ctx) {
+		var task models.Task
+		if err := cursor.Decode(&task); err != nil {
+			return err
+		}
+		column := task.BoardID.Hex() + "|" + string(task.Status)
+		prev, ok := last[column]
+		if !ok {
+			if prev, err = s.lastRank(ctx, task.BoardID, task.Status); err != nil {
+				return err
+			}
+		}
+		rank := rankBetween(prev, "")
+		last[column] = rank
+
+		_, err := s.db.UpdateOne(ctx, bson.M{"_id": task.ID}, bson.M{"$set": bson.M{"rank": rank}, "$inc": bson.M{"version": 1}})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
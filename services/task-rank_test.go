@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		prev, next, want string
+	}{
+		{"", "", "i"},
+		{"", "i", "9"},
+		{"i", "", "j"},
+		{"a", "c", "b"},
+		{"a", "b", "ai"},
+		{"a", "a1", "a0i"},
+		{"z", "", "z1"},
+		{"zz", "", "zz1"},
+		{"az", "", "b"},
+		{"", "1", "0i"},
+	}
+	for _, tt := range tests {
+		got := rankBetween(tt.prev, tt.next)
+		if got != tt.want {
+			t.Errorf("rankBetween(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+		}
+	}
+}
+
+// checkRank fails when rank is not strictly between prev and next or ends with the zero digit
+func checkRank(t *testing.T, prev, next, rank string) {
+	t.Helper()
+	if rank <= prev || (next != "" && rank >= next) {
+		t.Fatalf("rankBetween(%q, %q) = %q, not between them", prev, next, rank)
+	}
+	if strings.HasSuffix(rank, "0") {
+		t.Fatalf("rankBetween(%q, %q) = %q, ends with the zero digit", prev, next, rank)
+	}
+}
+
+func TestRankBetweenKeepsOrder(t *testing.T) {
+	// inserting again and again at the same place narrows the gap down to adjacent ranks
+	prev, next := "", ""
+	for i := 0; i < 200; i++ {
+		rank := rankBetween(prev, next)
+		checkRank(t, prev, next, rank)
+		next = rank
+	}
+
+	prev, next = "a", "b"
+	for i := 0; i < 200; i++ {
+		rank := rankBetween(prev, next)
+		checkRank(t, prev, next, rank)
+		prev = rank
+	}
+
+	prev = ""
+	for i := 0; i < 200; i++ {
+		rank := rankBetween(prev, "")
+		checkRank(t, prev, "", rank)
+		prev = rank
+	}
+}
@@ -3,11 +3,13 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 	"todoerbk/models"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TaskService struct {
@@ -18,8 +20,32 @@ func NewTaskService(db *mongo.Collection) *TaskService {
 	return &TaskService{db: db}
 }
 
+// EnsureIndexes creates the indexes backing task listings, title search and the reminder scheduler
+func (s *TaskService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "board_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "title", Value: "text"}}},
+		{Keys: bson.D{{Key: "reminders.remind_at", Value: 1}}},
+		{Keys: bson.D{{Key: "assignees", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "blocked_by", Value: 1}}},
+		{Keys: bson.D{{Key: "board_id", Value: 1}, {Key: "label_ids", Value: 1}}},
+		{Keys: bson.D{{Key: "attachments.uploader_id", Value: 1}}},
+		{Keys: bson.D{{Key: "recurrence.series_id", Value: 1}}},
+		{Keys: bson.D{{Key: "board_id", Value: 1}, {Key: "status", Value: 1}, {Key: "rank", Value: 1}}},
+	})
+	return err
+}
+
+// CreateTask inserts the task at the end of its status column
 func (s *TaskService) CreateTask(ctx context.Context, task *models.Task) error {
-	_, err := s.db.InsertOne(ctx, task)
+	rank, err := s.RankAtEnd(ctx, task.BoardID, task.Status)
+	if err != nil {
+		return err
+	}
+	task.Rank = rank
+	task.ChecklistProgress = checklistProgress(task.Checklist)
+	_, err = s.db.InsertOne(ctx, task)
 	return err
 }
 
@@ -29,7 +55,10 @@ func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
 		return errors.New("invalid task id")
 	}
 	_, err = s.db.DeleteOne(ctx, bson.M{"_id": objID})
-	return err
+	if err != nil {
+		return err
+	}
+	return s.removeFromBlockers(ctx, []interface{}{objID})
 }
 
 func (s *TaskService) GetTaskById(ctx context.Context, id string) (*models.Task, error) {
@@ -43,57 +72,65 @@ func (s *TaskService) GetTaskById(ctx context.Context, id string) (*models.Task,
 	return &task, err
 }
 
-func (s *TaskService) GetTasks(ctx context.Context) ([]models.Task, error) {
-	var tasks []models.Task
-	cursor, err := s.db.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
+// GetTasks returns one page of tasks matching the query and the cursor of the next page, empty on the last one
+func (s *TaskService) GetTasks(ctx context.Context, query models.TaskQuery, page models.PageRequest) ([]models.Task, string, error) {
+	return s.findTasks(ctx, bson.M{}, query, page, nil)
+}
 
-	for cursor.Next(ctx) {
-		var task models.Task
-		if err := cursor.Decode(&task); err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, task)
+func (s *TaskService) GetTasksByBoardId(ctx context.Context, board *models.Board, query models.TaskQuery, page models.PageRequest) ([]models.Task, string, error) {
+	// Boards list their tasks in the order of their workflow columns unless another sort is asked
+	if query.SortBy == "" {
+		query.SortBy = models.SortByPosition
 	}
-	return tasks, nil
+	return s.findTasks(ctx, bson.M{"board_id": board.ID}, query, page, board.Statuses())
 }
 
-func (s *TaskService) GetTasksByBoardId(ctx context.Context, boardId string) ([]models.Task, error) {
-	// Convertir el string a ObjectID
-	objID, err := primitive.ObjectIDFromHex(boardId)
+// GetTasksByAssignee returns one page of the tasks assigned to the user, across boards
+func (s *TaskService) GetTasksByAssignee(ctx context.Context, userID string, query models.TaskQuery, page models.PageRequest) ([]models.Task, string, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, errors.New("invalid board ID format")
+		return nil, "", err
 	}
 
-	var tasks []models.Task
-	// Usar el ObjectID en el filtro
-	cursor, err := s.db.Find(ctx, bson.M{"board_id": objID})
+	return s.findTasks(ctx, bson.M{"assignees": userObjectID}, query, page, nil)
+}
+
+// UpdateTask replaces the task only if it is still at task.Version and bumps the version on success
+func (s *TaskService) UpdateTask(ctx context.Context, id string, task *models.Task) error {
+	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return errors.New("invalid task id")
 	}
-	defer cursor.Close(ctx)
 
-	for cursor.Next(ctx) {
-		var task models.Task
-		if err := cursor.Decode(&task); err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, task)
+	task.ChecklistProgress = checklistProgress(task.Checklist)
+	expected := task.Version
+	task.Version = expected + 1
+	result, err := s.db.UpdateOne(ctx, versionFilter(objID, expected), bson.M{"$set": task})
+	if err != nil {
+		task.Version = expected
+		return err
 	}
-
-	return tasks, nil
+	if result.MatchedCount == 0 {
+		task.Version = expected
+		return ErrVersionMismatch
+	}
+	return nil
 }
 
-func (s *TaskService) UpdateTask(ctx context.Context, id string, task models.Task) error {
+// DeleteTaskAtVersion deletes the task only if it is still at the given version
+func (s *TaskService) DeleteTaskAtVersion(ctx context.Context, id string, version int64) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("invalid task id")
 	}
-	_, err = s.db.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": task})
-	return err
+	result, err := s.db.DeleteOne(ctx, versionFilter(objID, version))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrVersionMismatch
+	}
+	return s.removeFromBlockers(ctx, []interface{}{objID})
 }
 
 func (s *TaskService) DeleteTasksByBoardId(ctx context.Context, boardId string) error {
@@ -101,6 +138,100 @@ func (s *TaskService) DeleteTasksByBoardId(ctx context.Context, boardId string)
 	if err != nil {
 		return errors.New("invalid board ID format")
 	}
+	ids, err := s.db.Distinct(ctx, "_id", bson.M{"board_id": objID})
+	if err != nil {
+		return err
+	}
 	_, err = s.db.DeleteMany(ctx, bson.M{"board_id": objID})
+	if err != nil {
+		return err
+	}
+	return s.removeFromBlockers(ctx, ids)
+}
+
+type scoredTask struct {
+	models.Task `bson:",inline"`
+	Score       float64 `bson:"score"`
+}
+
+// searchTasks runs a text search on the titles of the tasks of the given boards, best matches first
+func (s *TaskService) searchTasks(ctx context.Context, boardIDs []primitive.ObjectID, text string, limit int64) ([]scoredTask, error) {
+	filter := bson.M{
+		"board_id": bson.M{"$in": boardIDs},
+		"$text":    bson.M{"$search": text},
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(limit)
+
+	cursor, err := s.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []scoredTask{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTasksWithDueReminders returns open tasks that have at least one unsent reminder due at now
+func (s *TaskService) GetTasksWithDueReminders(ctx context.Context, now time.Time, limit int64) ([]models.Task, error) {
+	filter := bson.M{
+		"status_category": bson.M{"$ne": models.CategoryDone},
+		"reminders": bson.M{"$elemMatch": bson.M{
+			"remind_at": bson.M{"$lte": now},
+			"sent_at":   bson.M{"$exists": false},
+		}},
+	}
+
+	cursor, err := s.db.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// ClaimDueReminders marks the task reminders due at now as sent.
+// It returns false when another worker claimed them first, so each reminder is sent once.
+func (s *TaskService) ClaimDueReminders(ctx context.Context, taskID primitive.ObjectID, now time.Time) (bool, error) {
+	unsent := bson.M{
+		"remind_at": bson.M{"$lte": now},
+		"sent_at":   bson.M{"$exists": false},
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"r.remind_at": bson.M{"$lte": now}, "r.sent_at": bson.M{"$exists": false}}},
+	})
+
+	result, err := s.db.UpdateOne(ctx,
+		bson.M{"_id": taskID, "reminders": bson.M{"$elemMatch": unsent}},
+		bson.M{"$set": bson.M{"reminders.$[r].sent_at": now}},
+		opts,
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RemoveLabelFromTasks clears a deleted catalog label from every task of the board
+func (s *TaskService) RemoveLabelFromTasks(ctx context.Context, boardID, labelID primitive.ObjectID) error {
+	_, err := s.db.UpdateMany(ctx,
+		bson.M{"board_id": boardID, "label_ids": labelID},
+		bson.M{
+			"$pull": bson.M{"label_ids": labelID},
+			"$set":  bson.M{"updated_at": time.Now().UTC()},
+			"$inc":  bson.M{"version": 1},
+		},
+	)
 	return err
 }
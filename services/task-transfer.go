@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RetargetTask resets the board scoped fields of the task for the target board. Labels are
+// matched by name with the target catalog, assignees that are not members are dropped and the
+// status is kept only if the target workflow has it. The rank is set when the task is written.
+func RetargetTask(task *models.Task, source, target *models.Board) {
+	task.BoardID = target.ID
+	task.Rank = ""
+
+	labelIDs := []primitive.ObjectID{}
+	for _, labelID := range task.LabelIDs {
+		label, ok := source.Label(labelID)
+		if !ok {
+			continue
+		}
+		for _, candidate := range target.Labels {
+			if strings.EqualFold(candidate.Name, label.Name) {
+				labelIDs = append(labelIDs, candidate.ID)
+				break
+			}
+		}
+	}
+	task.LabelIDs = labelIDs
+
+	assignees := []primitive.ObjectID{}
+	for _, assignee := range task.Assignees {
+		if target.IsMember(assignee) {
+			assignees = append(assignees, assignee)
+		}
+	}
+	task.Assignees = assignees
+	for i := range task.Checklist {
+		if task.Checklist[i].AssigneeID != nil && !target.IsMember(*task.Checklist[i].AssigneeID) {
+			task.Checklist[i].AssigneeID = nil
+		}
+	}
+
+	if _, ok := target.Column(task.Status); !ok {
+		task.Status = target.InitialStatus()
+	}
+}
+
+// PrepareCopy turns the task into a new copy of the original. What belongs to the original,
+// as its dependencies, recurrence, attachments, logged time and sent reminders, is left out.
+// The copy starts with its whole original estimate remaining, as the next occurrence of a series does.
+func PrepareCopy(task *models.Task, originalID primitive.ObjectID, now time.Time) {
+	task.ID = primitive.NewObjectID()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	task.Version = 1
+	task.CopiedFrom = &originalID
+	task.RolledOverFrom = nil
+	task.BlockedBy = nil
+	task.Recurrence = nil
+	task.Attachments = nil
+	task.LoggedMinutes = 0
+	task.RemainingEstimate = task.OriginalEstimate
+
+	checklist := []models.ChecklistItem{}
+	for _, item := range task.Checklist {
+		item.ID = primitive.NewObjectID()
+		checklist = append(checklist, item)
+	}
+	task.Checklist = checklist
+	reminders := []models.Reminder{}
+	for _, reminder := range task.Reminders {
+		reminder.SentAt = nil
+		reminders = append(reminders, reminder)
+	}
+	task.Reminders = reminders
+}
+
+// MoveTaskToBoard saves a task that was retargeted to another board at the end of its column.
+// Dependencies are only allowed between boards of the same owner, so without keepDependencies
+// the task loses its blockers and stops blocking other tasks.
+func (s *TaskService) MoveTaskToBoard(ctx context.Context, task *models.Task, keepDependencies bool) error {
+	rank, err := s.RankAtEnd(ctx, task.BoardID, task.Status)
+	if err != nil {
+		return err
+	}
+	task.Rank = rank
+	if !keepDependencies {
+		task.BlockedBy = nil
+	}
+	if err := s.UpdateTask(ctx, task.ID.Hex(), task); err != nil {
+		return err
+	}
+	if keepDependencies {
+		return nil
+	}
+	return s.removeFromBlockers(ctx, []interface{}{task.ID})
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"todoerbk/database"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// columnLockTTL frees the lock of a column if the request holding it dies
+	columnLockTTL = 5 * time.Second
+	// columnLockWait is how long a request waits for a busy column before giving up
+	columnLockWait  = 2 * time.Second
+	columnLockRetry = 20 * time.Millisecond
+)
+
+var ErrColumnBusy = errors.New("the column is being changed by other requests, try again")
+
+// WIPLimitError is returned when a task would go over the WIP limit of a column
+type WIPLimitError struct {
+	Status models.TaskStatus
+	Limit  int
+	Load   int
+}
+
+func (e *WIPLimitError) Error() string {
+	return fmt.Sprintf("column %s has reached its WIP limit of %d tasks (it has %d)", e.Status, e.Limit, e.Load)
+}
+
+// ColumnLoads returns the load of every column of the board from its task counts by status
+func ColumnLoads(board models.Board, counts map[models.TaskStatus]int) []models.ColumnLoad {
+	loads := make([]models.ColumnLoad, 0, len(board.Workflow))
+	for _, column := range board.Workflow {
+		load := models.ColumnLoad{Status: column.Status, Load: counts[column.Status], WIPLimit: column.WIPLimit}
+		load.OverLimit = column.WIPLimit > 0 && load.Load > column.WIPLimit
+		loads = append(loads, load)
+	}
+	return loads
+}
+
+// lockColumn takes the lock of a column of a board and returns the function that releases it.
+// Only one request at a time can count and fill the column.
+func (s *TaskService) lockColumn(ctx context.Context, boardID primitive.ObjectID, status models.TaskStatus) (func(), error) {
+	// bson.D keeps the field order, embedded _id documents only match with the same order
+	return s.lock(ctx, bson.D{{Key: "board_id", Value: boardID}, {Key: "status", Value: status}}, ErrColumnBusy)
+}
+
+// lock takes the lock named by key and returns the function that releases it, or busy when
+// another request keeps it for too long. The lock is a document whose _id is the key, inserting
+// it while another request holds it fails with a duplicate key.
+func (s *TaskService) lock(ctx context.Context, key bson.D, busy error) (func(), error) {
+	locks := s.db.Database().Collection(database.ColumnLocksCollection)
+	owner := primitive.NewObjectID()
+	deadline := time.Now().Add(columnLockWait)
+
+	for {
+		now := time.Now().UTC()
+		_, err := locks.UpdateOne(ctx,
+			bson.M{"_id": key, "locked_until": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"locked_until": now.Add(columnLockTTL), "owner": owner}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			return func() {
+				locks.DeleteOne(context.Background(), bson.M{"_id": key, "owner": owner})
+			}, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, busy
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(columnLockRetry):
+		}
+	}
+}
+
+// EnterColumn runs write, the change that puts a task coming from the status from (empty for
+// new tasks) in the column of the board, only if the column has room for it. The count and
+// the write happen under the column lock, so concurrent requests cannot overshoot the limit.
+// Forced changes, moves inside the column and columns without limit skip the check.
+func (s *TaskService) EnterColumn(ctx context.Context, boardID, taskID primitive.ObjectID, from models.TaskStatus, column models.WorkflowColumn, force bool, write func() error) error {
+	if column.WIPLimit == 0 || force || from == column.Status {
+		return write()
+	}
+
+	unlock, err := s.lockColumn(ctx, boardID, column.Status)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	load, err := s.db.CountDocuments(ctx, bson.M{
+		"board_id": boardID,
+		"status":   column.Status,
+		"_id":      bson.M{"$ne": taskID},
+	})
+	if err != nil {
+		return err
+	}
+	if int(load) >= column.WIPLimit {
+		return &WIPLimitError{Status: column.Status, Limit: column.WIPLimit, Load: int(load)}
+	}
+	return write()
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// NormalizeStatus makes statuses case insensitive: they are stored trimmed and upper case
+func NormalizeStatus(status models.TaskStatus) models.TaskStatus {
+	return models.TaskStatus(strings.ToUpper(strings.TrimSpace(string(status))))
+}
+
+// NormalizeWorkflow normalizes the statuses of the columns and checks that they are unique,
+// that the transitions point to columns of the workflow and that some column completes tasks
+func NormalizeWorkflow(columns []models.WorkflowColumn) ([]models.WorkflowColumn, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: it needs at least one column", ErrInvalidWorkflow)
+	}
+
+	normalized := make([]models.WorkflowColumn, 0, len(columns))
+	seen := map[models.TaskStatus]bool{}
+	hasDone := false
+	for _, column := range columns {
+		column.Status = NormalizeStatus(column.Status)
+		if column.Status == "" {
+			return nil, fmt.Errorf("%w: every column needs a status", ErrInvalidWorkflow)
+		}
+		if seen[column.Status] {
+			return nil, fmt.Errorf("%w: status %s is repeated", ErrInvalidWorkflow, column.Status)
+		}
+		if !column.Category.IsValid() {
+			return nil, fmt.Errorf("%w: category of %s must be todo, in_progress or done", ErrInvalidWorkflow, column.Status)
+		}
+		seen[column.Status] = true
+		hasDone = hasDone || column.Category == models.CategoryDone
+		normalized = append(normalized, column)
+	}
+	if !hasDone {
+		return nil, fmt.Errorf("%w: at least one column must have the done category", ErrInvalidWorkflow)
+	}
+
+	for i := range normalized {
+		for j, status := range normalized[i].Transitions {
+			status = NormalizeStatus(status)
+			if !seen[status] {
+				return nil, fmt.Errorf("%w: transition from %s to unknown status %s", ErrInvalidWorkflow, normalized[i].Status, status)
+			}
+			normalized[i].Transitions[j] = status
+		}
+	}
+	return normalized, nil
+}
+
+// SetWorkflow replaces the workflow of the board, the workflow must be normalized
+func (s *BoardService) SetWorkflow(ctx context.Context, boardID string, workflow []models.WorkflowColumn) (*models.Board, error) {
+	return s.modifyBoard(ctx, boardID, func(board *models.Board) error {
+		board.Workflow = workflow
+		return nil
+	})
+}
+
+// EnsureWorkflows gives the default workflow to the boards created before workflows existed
+func (s *BoardService) EnsureWorkflows(ctx context.Context) error {
+	_, err := s.db.UpdateMany(ctx,
+		bson.M{"$or": []bson.M{{"workflow": bson.M{"$exists": false}}, {"workflow": bson.M{"$size": 0}}, {"workflow": nil}}},
+		bson.M{"$set": bson.M{"workflow": models.DefaultWorkflow()}, "$inc": bson.M{"version": 1}},
+	)
+	return err
+}
+
+// EnsureStatusCategories fills the category of the tasks stored before workflows existed.
+// Their boards get the default workflow, so the category follows from the status.
+func (s *TaskService) EnsureStatusCategories(ctx context.Context) error {
+	missing := bson.M{"$or": []bson.M{{"status_category": bson.M{"$exists": false}}, {"status_category": ""}}}
+	for _, column := range models.DefaultWorkflow() {
+		_, err := s.db.UpdateMany(ctx,
+			bson.M{"$and": []bson.M{missing, {"status": column.Status}}},
+			bson.M{"$set": bson.M{"status_category": column.Category}, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := s.db.UpdateMany(ctx, missing,
+		bson.M{"$set": bson.M{"status_category": models.CategoryTodo}, "$inc": bson.M{"version": 1}},
+	)
+	return err
+}
+
+// SyncStatusCategories copies the categories of the workflow to the tasks of the board
+func (s *TaskService) SyncStatusCategories(ctx context.Context, boardID primitive.ObjectID, workflow []models.WorkflowColumn) error {
+	for _, column := range workflow {
+		_, err := s.db.UpdateMany(ctx,
+			bson.M{"board_id": boardID, "status": column.Status, "status_category": bson.M{"$ne": column.Category}},
+			bson.M{"$set": bson.M{"status_category": column.Category}, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountTasksByStatus returns how many tasks of the board are in each status
+func (s *TaskService) CountTasksByStatus(ctx context.Context, boardID primitive.ObjectID) (map[models.TaskStatus]int, error) {
+	cursor, err := s.db.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"board_id": boardID}},
+		{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Status models.TaskStatus `bson:"_id"`
+		Count  int               `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := map[models.TaskStatus]int{}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+	"todoerbk/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrTimerRunning = errors.New("a timer is already running, stop it first")
+var ErrNoTimer = errors.New("no timer is running on this task")
+var ErrWorkLogNotFound = errors.New("work log not found")
+
+// WorkLogService keeps the work logs and the running timers, and the logged time of the tasks
+type WorkLogService struct {
+	db           *mongo.Collection
+	timers       *mongo.Collection
+	TaskService  *TaskService
+	BoardService *BoardService
+}
+
+func NewWorkLogService(db *mongo.Collection, timers *mongo.Collection, taskService *TaskService, boardService *BoardService) *WorkLogService {
+	return &WorkLogService{db: db, timers: timers, TaskService: taskService, BoardService: boardService}
+}
+
+// EnsureIndexes creates the indexes backing the task work logs and the timesheets
+func (s *WorkLogService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: 1}}},
+		{Keys: bson.D{{Key: "board_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.timers.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "task_id", Value: 1}}})
+	return err
+}
+
+// StartTimer starts the timer of the user on the task. The timer id is the user id, so a
+// second timer of the same user fails with ErrTimerRunning and the running one is returned.
+// A timer left running on a task that was deleted since is dropped.
+func (s *WorkLogService) StartTimer(ctx context.Context, userID, taskID primitive.ObjectID) (*models.Timer, error) {
+	timer := models.Timer{UserID: userID, TaskID: taskID, StartedAt: time.Now().UTC()}
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := s.timers.InsertOne(ctx, timer)
+		if !mongo.IsDuplicateKeyError(err) {
+			if err != nil {
+				return nil, err
+			}
+			return &timer, nil
+		}
+
+		running, err := s.GetTimer(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if running == nil {
+			continue
+		}
+		_, err = s.TaskService.GetTaskById(ctx, running.TaskID.Hex())
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return running, ErrTimerRunning
+		}
+		if _, err := s.timers.DeleteOne(ctx, bson.M{"_id": userID, "task_id": running.TaskID}); err != nil {
+			return nil, err
+		}
+	}
+	return nil, ErrTimerRunning
+}
+
+// GetTimer returns the running timer of the user, nil when there is none
+func (s *WorkLogService) GetTimer(ctx context.Context, userID primitive.ObjectID) (*models.Timer, error) {
+	var timer models.Timer
+	err := s.timers.FindOne(ctx, bson.M{"_id": userID}).Decode(&timer)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// StopTimer stops the timer of the user on the task and logs the time since it started,
+// rounded up to the minute
+func (s *WorkLogService) StopTimer(ctx context.Context, userID primitive.ObjectID, task *models.Task) (*models.WorkLog, *models.Task, error) {
+	var timer models.Timer
+	err := s.timers.FindOneAndDelete(ctx, bson.M{"_id": userID, "task_id": task.ID}).Decode(&timer)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrNoTimer
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	minutes := int(math.Ceil(time.Since(timer.StartedAt).Minutes()))
+	if minutes < 1 {
+		minutes = 1
+	}
+	workLog := &models.WorkLog{
+		TaskID:    task.ID,
+		BoardID:   task.BoardID,
+		UserID:    userID,
+		StartedAt: timer.StartedAt,
+		Minutes:   minutes,
+		Source:    models.WorkLogTimer,
+	}
+	updated, err := s.LogWork(ctx, workLog, nil)
+	return workLog, updated, err
+}
+
+// LogWork stores the work log and adds its time to the task. The remaining estimate is set to
+// remaining when given, otherwise it goes down by the time logged without going below zero.
+func (s *WorkLogService) LogWork(ctx context.Context, workLog *models.WorkLog, remaining *int) (*models.Task, error) {
+	workLog.ID = primitive.NewObjectID()
+	workLog.CreatedAt = time.Now().UTC()
+	if _, err := s.db.InsertOne(ctx, workLog); err != nil {
+		return nil, err
+	}
+
+	return s.TaskService.modifyTask(ctx, workLog.TaskID.Hex(), func(task *models.Task) error {
+		task.LoggedMinutes += workLog.Minutes
+		switch {
+		case remaining != nil:
+			task.RemainingEstimate = *remaining
+		case task.RemainingEstimate > workLog.Minutes:
+			task.RemainingEstimate -= workLog.Minutes
+		default:
+			task.RemainingEstimate = 0
+		}
+		return nil
+	})
+}
+
+func (s *WorkLogService) GetWorkLogById(ctx context.Context, id string) (*models.WorkLog, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWorkLogNotFound
+	}
+	var workLog models.WorkLog
+	err = s.db.FindOne(ctx, bson.M{"_id": objID}).Decode(&workLog)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWorkLogNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &workLog, nil
+}
+
+// GetWorkLogsByTaskId returns one page of the work logs of the task, oldest first
+func (s *WorkLogService) GetWorkLogsByTaskId(ctx context.Context, taskID primitive.ObjectID, page models.PageRequest) ([]models.WorkLog, string, error) {
+	return findPage(ctx, s.db, bson.M{"task_id": taskID}, page, func(workLog models.WorkLog) (interface{}, primitive.ObjectID) {
+		return workLog.CreatedAt, workLog.ID
+	})
+}
+
+// DeleteWorkLog removes the work log and its time from the task, the remaining estimate is left as is
+func (s *WorkLogService) DeleteWorkLog(ctx context.Context, workLog *models.WorkLog) (*models.Task, error) {
+	result, err := s.db.DeleteOne(ctx, bson.M{"_id": workLog.ID})
+	if err != nil {
+		return nil, err
+	}
+	if result.DeletedCount == 0 {
+		return nil, ErrWorkLogNotFound
+	}
+	return s.TaskService.modifyTask(ctx, workLog.TaskID.Hex(), func(task *models.Task) error {
+		task.LoggedMinutes -= workLog.Minutes
+		if task.LoggedMinutes < 0 {
+			task.LoggedMinutes = 0
+		}
+		return nil
+	})
+}
+
+// GetWorkLogsByUser returns the work logs of the user started in [from, to), oldest first
+func (s *WorkLogService) GetWorkLogsByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]models.WorkLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.db.Find(ctx, bson.M{"user_id": userID, "started_at": bson.M{"$gte": from, "$lt": to}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	workLogs := []models.WorkLog{}
+	if err := cursor.All(ctx, &workLogs); err != nil {
+		return nil, err
+	}
+	return workLogs, nil
+}
+
+// GetTimesheet returns the work logs of the user on the days of the query with their task and
+// board titles, and the minutes logged each day. Days are UTC days.
+func (s *WorkLogService) GetTimesheet(ctx context.Context, userID primitive.ObjectID, query models.TimesheetQuery) (*models.Timesheet, error) {
+	workLogs, err := s.GetWorkLogsByUser(ctx, userID, query.From, query.To.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs, boardIDs := []primitive.ObjectID{}, []primitive.ObjectID{}
+	for _, workLog := range workLogs {
+		taskIDs = append(taskIDs, workLog.TaskID)
+		boardIDs = append(boardIDs, workLog.BoardID)
+	}
+	tasks, err := s.TaskService.GetTasksByIds(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	boards, err := s.BoardService.GetBoardsByIDs(ctx, boardIDs)
+	if err != nil {
+		return nil, err
+	}
+	taskTitles, boardTitles := map[primitive.ObjectID]string{}, map[primitive.ObjectID]string{}
+	for _, task := range tasks {
+		taskTitles[task.ID] = task.Title
+	}
+	for _, board := range boards {
+		boardTitles[board.ID] = board.Title
+	}
+
+	timesheet := &models.Timesheet{
+		UserID:       userID,
+		From:         query.From.Format("2006-01-02"),
+		To:           query.To.Format("2006-01-02"),
+		DailyMinutes: map[string]int{},
+		Entries:      []models.TimesheetEntry{},
+	}
+	for day := query.From; !day.After(query.To); day = day.AddDate(0, 0, 1) {
+		timesheet.DailyMinutes[day.Format("2006-01-02")] = 0
+	}
+	for _, workLog := range workLogs {
+		timesheet.TotalMinutes += workLog.Minutes
+		timesheet.DailyMinutes[workLog.StartedAt.UTC().Format("2006-01-02")] += workLog.Minutes
+		timesheet.Entries = append(timesheet.Entries, models.TimesheetEntry{
+			WorkLog:    workLog,
+			TaskTitle:  taskTitles[workLog.TaskID],
+			BoardTitle: boardTitles[workLog.BoardID],
+		})
+	}
+	return timesheet, nil
+}
+
+// MoveWorkLogsToBoard follows a task that changed boards
+func (s *WorkLogService) MoveWorkLogsToBoard(ctx context.Context, taskID, boardID primitive.ObjectID) error {
+	_, err := s.db.UpdateMany(ctx, bson.M{"task_id": taskID}, bson.M{"$set": bson.M{"board_id": boardID}})
+	return err
+}
+
+// DeleteWorkLogsByTaskIds deletes the work logs and the running timers of deleted tasks
+func (s *WorkLogService) DeleteWorkLogsByTaskIds(ctx context.Context, taskIDs []primitive.ObjectID) error {
+	if _, err := s.db.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}}); err != nil {
+		return err
+	}
+	_, err := s.timers.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	return err
+}
+
+// DeleteWorkLogsByBoardId deletes the work logs of the tasks of a deleted board.
+// Their running timers are dropped the next time their users start one.
+func (s *WorkLogService) DeleteWorkLogsByBoardId(ctx context.Context, boardID string) error {
+	objID, err := primitive.ObjectIDFromHex(boardID)
+	if err != nil {
+		return errors.New("invalid board ID format")
+	}
+	_, err = s.db.DeleteMany(ctx, bson.M{"board_id": objID})
+	return err
+}
+
+// ResetLoggedTime clears the time logged on a task that is new, its remaining estimate is the
+// original estimate when it has none
+func ResetLoggedTime(task *models.Task) {
+	task.LoggedMinutes = 0
+	if task.RemainingEstimate == 0 {
+		task.RemainingEstimate = task.OriginalEstimate
+	}
+}
+
+// GetTimeTotals adds up the estimates and the logged time of the tasks of the board
+func (s *TaskService) GetTimeTotals(ctx context.Context, boardID primitive.ObjectID) (models.TimeTotals, error) {
+	totals := models.TimeTotals{}
+	cursor, err := s.db.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"board_id": boardID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                        nil,
+			"original_estimate_minutes":  bson.M{"$sum": "$original_estimate_minutes"},
+			"remaining_estimate_minutes": bson.M{"$sum": "$remaining_estimate_minutes"},
+			"logged_minutes":             bson.M{"$sum": "$logged_minutes"},
+		}}},
+	})
+	if err != nil {
+		return totals, err
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&totals); err != nil {
+			return totals, err
+		}
+	}
+	return totals, cursor.Err()
+}